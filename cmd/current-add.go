@@ -6,9 +6,11 @@ package cmd
 import (
 	"log/slog"
 	"os"
+	"strings"
 
 	"github.com/charmbracelet/huh"
 	"github.com/spf13/cobra"
+	"github.com/withholm/polyenv/internal/model"
 	"github.com/withholm/polyenv/internal/tui"
 )
 
@@ -43,9 +45,21 @@ func generateAddCommand() *cobra.Command {
 
 	addSecretCmds = append(addSecretCmds, addSecretCmd)
 
+	var addRefCmd = &cobra.Command{
+		Use:   "ref [key] [vault]/[remote key]",
+		Short: "add a vault reference to your env files, without the tui",
+		Long: `
+		sets key to polyenv://{vault}/{remote key} in your .env files.
+		the value is pulled from the vault when running pull or export.
+		the key is updated where it is defined, else it is added to .env.{env}
+	`,
+		Args: cobra.ExactArgs(2),
+		Run:  addRef,
+	}
+
 	var addCmd = &cobra.Command{
-		Use:   "add [secret|vault] [arguments]",
-		Short: "add a new secret or vault to the environment",
+		Use:   "add [secret|vault|ref] [arguments]",
+		Short: "add a new secret, vault or vault reference to the environment",
 		Long: `
 		add will add a new secret or vault to the environment
 	`,
@@ -55,6 +69,7 @@ func generateAddCommand() *cobra.Command {
 
 	addCmd.AddCommand(addVaultCmd)
 	addCmd.AddCommand(addSecretCmd)
+	addCmd.AddCommand(addRefCmd)
 
 	return addCmd
 }
@@ -95,6 +110,21 @@ func addSecret(cmd *cobra.Command, args []string) {
 	PolyenvFile.Save()
 }
 
+func addRef(cmd *cobra.Command, args []string) {
+	if len(args) != 2 {
+		slog.Error("expected a key and a reference: add ref {key} {vault}/{remote key}")
+		os.Exit(1)
+	}
+	ref := strings.TrimPrefix(args[1], model.VaultReferencePrefix)
+	vault, remoteKey, _ := strings.Cut(ref, "/")
+	env, err := PolyenvFile.AddVaultReference(args[0], vault, remoteKey)
+	if err != nil {
+		slog.Error("failed to add vault reference", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("added vault reference", "key", env.Key, "reference", env.Value, "file", env.File)
+}
+
 func addVault(cmd *cobra.Command, args []string) {
 	PolyenvFile.TuiAddVault("", map[string]any{})
 	PolyenvFile.Save()
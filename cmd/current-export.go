@@ -10,12 +10,30 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/withholm/polyenv/internal/model"
 	"github.com/withholm/polyenv/internal/plugin"
+	"github.com/withholm/polyenv/internal/polyenvfile"
 	"github.com/withholm/polyenv/internal/tools"
+	"github.com/withholm/polyenv/internal/tui"
 )
 
 var writerFlag string
 var formatFlag string
+var fromFlag string
+var otsOptions plugin.OtsOptions
+var separatorFlag string
+var k8sOptions plugin.K8sFormatter
+var pathFlag string
+var githubSummaryFlag bool
+var writeModeFlag string
+var unsetFlag bool
+var composeServiceFlag string
+var tfVarFlag bool
+var launchProfileFlag string
+var templateFlag string
+var exportFilter model.ExportFilter
+var resolveFlag bool
+var noExpandFlag bool
 
 // export --to {writer} --as {format}
 
@@ -25,8 +43,11 @@ func generateEnvCommand() *cobra.Command {
 		Short: "export environment variables to a given format and destination",
 		Long: `
 		export environment variables to a given format and destination. defaults to json output to stdout
+		use --from to read values from another source than the env files, ie 'stdin', 'env' or a file path
 	`,
-		Run: ExportEnv,
+		// catches '--redact last:3', where the mode would be read as an argument
+		Args: cobra.NoArgs,
+		Run:  ExportEnv,
 	}
 
 	envCmd.Flags().StringVar(&writerFlag, "to", "stdout", fmt.Sprintf("where to output to: %v", tools.MapKeySlice(plugin.Writers)))
@@ -48,16 +69,58 @@ func generateEnvCommand() *cobra.Command {
 		slog.Error("failed to add completion on 'as' flag", "err", err)
 	}
 
+	envCmd.Flags().StringVar(&fromFlag, "from", "", fmt.Sprintf("read values from a source instead of env files: %v or a file path", tools.MapKeySlice(plugin.Sources)))
+	err = envCmd.RegisterFlagCompletionFunc("from", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return tools.MapKeySlice(plugin.Sources), cobra.ShellCompDirectiveDefault
+	})
+	if err != nil {
+		slog.Error("failed to add completion on 'from' flag", "err", err)
+	}
+
+	// filters
+	envCmd.Flags().StringSliceVar(&exportFilter.Include, "include", []string{}, "only export keys matching these glob patterns (case insensitive). ie 'DB_*'")
+	envCmd.Flags().StringSliceVar(&exportFilter.Exclude, "exclude", []string{}, "dont export keys matching these glob patterns (case insensitive)")
+	envCmd.Flags().BoolVar(&exportFilter.OnlySecrets, "only-secrets", false, "only export secrets (from a vault or detected)")
+	envCmd.Flags().BoolVar(&exportFilter.NoSecrets, "no-secrets", false, "dont export secrets (from a vault or detected)")
+	envCmd.Flags().StringSliceVar(&exportFilter.Files, "file", []string{}, "only export values from files matching these glob patterns. ie '.env.secret.*'")
+	envCmd.Flags().StringVar(&exportFilter.StripPrefix, "strip-prefix", "", "remove this prefix from keys")
+	envCmd.Flags().StringVar(&exportFilter.Prefix, "prefix", "", "add this prefix to keys")
+	envCmd.MarkFlagsMutuallyExclusive("only-secrets", "no-secrets")
+	envCmd.Flags().BoolVar(&resolveFlag, "resolve", false, "only export the effective value of keys defined in multiple files")
+	envCmd.Flags().BoolVar(&noExpandFlag, "no-expand", false, "keep references to other keys (${VAR}) and vaults (polyenv://) as written instead of resolving them")
+
+	envCmd.Flags().BoolVar(&unsetFlag, "unset", false, "shell formats (posix, pwsh, fish, nu, cmd): output commands that unload the values instead")
+	envCmd.Flags().StringVar(&separatorFlag, "separator", plugin.DefaultNestSeparator, "separator used to nest keys in yaml, toml and properties (DB__HOST -> db.host)")
+
+	// k8s formatters
+	envCmd.Flags().StringVar(&k8sOptions.ResourceName, "k8s-name", "", "k8s: name of the Secret/ConfigMap. defaults to the environment name")
+	envCmd.Flags().StringVar(&k8sOptions.Namespace, "k8s-namespace", "", "k8s: namespace of the Secret/ConfigMap")
+	envCmd.Flags().StringToStringVar(&k8sOptions.Labels, "k8s-label", map[string]string{}, "k8s: labels to add, key=value. can be used multiple times")
+	envCmd.Flags().BoolVar(&k8sOptions.Stream, "k8s-stream", false, "k8s: output both ConfigMap and Secret as a multi document stream")
+
+	envCmd.Flags().StringVar(&pathFlag, "path", "", "file path for writers that write to a file (file, gitlab-dotenv, bitbucket, jenkins)")
+	envCmd.Flags().StringVar(&writeModeFlag, "write-mode", string(plugin.FileOverwrite), fmt.Sprintf("file: how to write to an existing file %v. merge supports dotenv and json", plugin.FileWriteModes))
+
+	envCmd.Flags().BoolVar(&githubSummaryFlag, "github-summary", false, "github: add a table of exported keys (no values) to the step summary")
+
+	envCmd.Flags().BoolVar(&tfVarFlag, "tf-var", false, "posix/pwsh: export as terraform input variables (TF_VAR_name, lowercased)")
+	envCmd.Flags().StringVar(&templateFlag, "template", "", "template: path to a go template, or name of a template next to the polyenv file ({name}"+plugin.TemplateSuffix+")")
+	envCmd.Flags().StringVar(&launchProfileFlag, "launch-profile", "", "launchsettings: name of the profile. defaults to the environment name")
+	envCmd.Flags().StringVar(&composeServiceFlag, "compose-service", "", "compose: wrap the environment in a service, making it a compose override file")
+
+	// ots writer
+	envCmd.Flags().StringVar(&otsOptions.Region, "ots-region", "", fmt.Sprintf("ots: onetimesecret.com region %v. defaults to eu", plugin.OtsRegions))
+	envCmd.Flags().StringVar(&otsOptions.URL, "ots-url", "", "ots: base url of a self hosted instance")
+	envCmd.Flags().StringVar(&otsOptions.Recipient, "ots-recipient", "", "ots: email to send the link to. requires POLYENV_OTS_USERNAME and POLYENV_OTS_API_KEY")
+	envCmd.Flags().StringVar(&otsOptions.Passphrase, "ots-passphrase", "", "ots: passphrase needed to reveal the secret. skips the form")
+	envCmd.Flags().StringVar(&otsOptions.TTL, "ots-ttl", "", "ots: how long the secret is valid, days or iso duration (max P7D). skips the form")
+	envCmd.Flags().StringVar(&otsOptions.Output, "ots-output", "text", "ots: output as 'text' or 'json'")
+
 	return envCmd
 }
 
 func ExportEnv(cmd *cobra.Command, args []string) {
-	list, err := PolyenvFile.AllDotenvValues()
-	if err != nil {
-		slog.Error("failed to list env", "error", err)
-		os.Exit(1)
-	}
-
+	var err error
 	slog.Debug("output", "as", formatFlag, "to", writerFlag)
 
 	wFunc, ok := tools.InequalFindInMap(plugin.Writers, writerFlag)
@@ -66,6 +129,16 @@ func ExportEnv(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 	writer := wFunc()
+	if ots, ok := writer.(*plugin.OtsWriter); ok {
+		ots.Options = otsOptions
+		ots.Options.Interactive = tui.IsTTY() && !cmd.Flags().Changed("ots-passphrase") && !cmd.Flags().Changed("ots-ttl")
+	}
+	if gh, ok := writer.(*plugin.GithubWriter); ok {
+		gh.Summary = githubSummaryFlag
+	}
+	if ci, ok := writer.(*plugin.CIFileWriter); ok {
+		ci.Path = pathFlag
+	}
 	plugin.SelectedWriter = writer
 
 	//detect format that can be used with the given writer
@@ -96,8 +169,103 @@ func ExportEnv(cmd *cobra.Command, args []string) {
 
 	formatter := fmtFunc()
 
-	if strings.EqualFold(formatFlag, "stats") {
-		formatter = &plugin.StatsFormatter{PolyenvFile: PolyenvFile}
+	if fw, ok := writer.(*plugin.FileWriter); ok {
+		fw.Path = pathFlag
+		fw.Mode = plugin.FileWriteMode(strings.ToLower(writeModeFlag))
+		fw.Format = formatter.Name()
+	}
+
+	switch f := formatter.(type) {
+	case *plugin.PosixFormatter:
+		f.Unset = unsetFlag
+		f.TfVar = tfVarFlag
+	case *plugin.PwshFormatter:
+		f.Unset = unsetFlag
+		f.TfVar = tfVarFlag
+	case *plugin.FishFormatter:
+		f.Unset = unsetFlag
+	case *plugin.NuFormatter:
+		f.Unset = unsetFlag
+	case *plugin.CmdFormatter:
+		f.Unset = unsetFlag
+	case *plugin.InspectFormatter:
+		f.PolyenvFile = PolyenvFile
+	case *plugin.TemplateFormatter:
+		f.Template = templateFlag
+		if PolyenvFile != nil && PolyenvFile.Path != "" {
+			f.Dir = PolyenvFile.Path
+		}
+	case *plugin.PropertiesFormatter:
+		f.Separator = separatorFlag
+	case *plugin.LaunchSettingsFormatter:
+		f.Profile = launchProfileFlag
+		if f.Profile == "" && Environment != "" {
+			f.Profile = Environment
+		}
+	case *plugin.ComposeFormatter:
+		f.Service = composeServiceFlag
+	case *plugin.YAMLFormatter:
+		f.Separator = separatorFlag
+	case *plugin.TOMLFormatter:
+		f.Separator = separatorFlag
+	case *plugin.K8sFormatter:
+		f.ResourceName = k8sOptions.ResourceName
+		// only an explicit --k8s-name is validated as is, the environment name is made valid
+		if f.ResourceName == "" && Environment != "" {
+			f.ResourceName = plugin.K8sName(Environment)
+		}
+		f.Namespace = k8sOptions.Namespace
+		f.Labels = k8sOptions.Labels
+		f.Stream = k8sOptions.Stream
+	}
+
+	var list []model.StoredEnv
+	if fromFlag != "" {
+		src, e := plugin.FindSource(fromFlag)
+		if e != nil {
+			slog.Error("failed to find source", "from", fromFlag, "error", e)
+			os.Exit(1)
+		}
+		plugin.SelectedSource = src
+		slog.Debug("reading from source", "source", src.Name(), "from", fromFlag)
+		list, err = src.Get(fromFlag)
+		// values from sources are not tied to the polyenv file, so secrets has to be detected
+		for i := range list {
+			list[i].IsSecret, _ = list[i].DetectSecret()
+		}
+	} else if noExpandFlag {
+		list, err = PolyenvFile.AllDotenvValuesUnexpanded()
+	} else if _, ok := formatter.(*plugin.InspectFormatter); ok {
+		// the report does not need the values of vault references
+		list, err = PolyenvFile.AllDotenvValues()
+	} else {
+		list, err = PolyenvFile.AllDotenvValuesWithSecrets(cmd.Context())
+	}
+	if err != nil {
+		slog.Error("failed to list env", "error", err)
+		os.Exit(1)
+	}
+
+	list, err = exportFilter.Apply(list)
+	if err != nil {
+		slog.Error("failed to filter values", "error", err)
+		os.Exit(1)
+	}
+	slog.Debug("filtered values", "count", len(list))
+
+	if resolveFlag {
+		list = polyenvfile.Resolve(list)
+		slog.Debug("resolved values", "count", len(list))
+	}
+
+	redact, err := model.ParseRedact(RedactFlag)
+	if err != nil {
+		slog.Error("invalid redact mode", "error", err)
+		os.Exit(1)
+	}
+	if redact.Mode != model.RedactNone {
+		slog.Debug("redacting secrets", "mode", redact.Mode)
+		list = redact.Apply(list)
 	}
 
 	//format output
@@ -108,6 +276,9 @@ func ExportEnv(cmd *cobra.Command, args []string) {
 	}
 
 	//write output
+	if w, ok := writer.(model.EnvAwareWriter); ok {
+		w.SetEnv(list)
+	}
 	err = writer.Write(formatted)
 	if err != nil {
 		slog.Error("failed to write output", "error", err)
@@ -0,0 +1,174 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss/list"
+	"github.com/spf13/cobra"
+	"github.com/withholm/polyenv/internal/model"
+	"github.com/withholm/polyenv/internal/plugin"
+	"github.com/withholm/polyenv/internal/polyenvfile"
+	"github.com/withholm/polyenv/internal/tools"
+	"github.com/withholm/polyenv/internal/tui"
+)
+
+var importYesFlag bool
+var importSkipExistingFlag bool
+var importPassphraseFlag string
+
+func generateImportCommand() *cobra.Command {
+	var importCmd = &cobra.Command{
+		Use:   "import [source]",
+		Short: "import values from a file, stdin, link or another environment",
+		Long: `
+		import values into the environment. source can be:
+		- a file path (json, json array or dotenv)
+		- 'stdin' or '-' for piped data
+		- '!{env}' to copy values from another environment
+		- a onetimesecret link, or 'ots:{key}'
+		- any other source: ` + strings.Join(tools.MapKeySlice(plugin.Sources), ", ") + `
+
+		existing keys are updated in the file they are defined in.
+		new secrets are written to .env.secret.{env} if 'use dot secret file for secrets' is enabled, else .env.{env}
+	`,
+		Args: cobra.ExactArgs(1),
+		Run:  importEnv,
+	}
+	importCmd.Flags().BoolVarP(&importYesFlag, "yes", "y", false, "dont ask for confirmation")
+	importCmd.Flags().StringVar(&importPassphraseFlag, "passphrase", "", "passphrase for sources that need it (ots). can also be set with POLYENV_OTS_PASSPHRASE")
+	importCmd.Flags().BoolVar(&importSkipExistingFlag, "skip-existing", false, "dont overwrite keys that already exists with a different value")
+	return importCmd
+}
+
+func importEnv(cmd *cobra.Command, args []string) {
+	from := args[0]
+	var src model.Source
+	if strings.HasPrefix(from, "!") {
+		src = &envFilesSource{}
+	} else {
+		var err error
+		src, err = plugin.FindSource(from)
+		if err != nil {
+			slog.Error("failed to find source", "from", from, "error", err)
+			os.Exit(1)
+		}
+	}
+	if ots, ok := src.(*plugin.OneTimeSecretSource); ok {
+		ots.Passphrase = importPassphraseFlag
+	}
+	plugin.SelectedSource = src
+
+	slog.Debug("importing", "source", src.Name(), "from", from)
+	values, err := src.Get(from)
+	if err != nil {
+		slog.Error("failed to read source", "source", src.Name(), "error", err)
+		os.Exit(1)
+	}
+	if len(values) == 0 {
+		slog.Info("nothing to import")
+		return
+	}
+
+	plan, err := PolyenvFile.PlanImport(values)
+	if err != nil {
+		slog.Error("failed to plan import", "error", err)
+		os.Exit(1)
+	}
+
+	fmt.Println(importPreview(plan, importSkipExistingFlag))
+	if len(plan.Filter(polyenvfile.ImportUnchanged)) == len(plan.Items) {
+		slog.Info("all values are up to date")
+		return
+	}
+
+	if !importYesFlag {
+		if !tui.IsTTY() {
+			slog.Error("cannot confirm import in a non interactive terminal. use --yes to import anyway")
+			os.Exit(1)
+		}
+		confirm := false
+		tui.RunHuh(huh.NewForm(
+			huh.NewGroup(
+				huh.NewConfirm().
+					Title(fmt.Sprintf("import %d values into !%s?", len(plan.Items), Environment)).
+					Affirmative("Yes").
+					Negative("No").
+					Value(&confirm),
+			),
+		))
+		if !confirm {
+			slog.Info("import cancelled")
+			return
+		}
+	}
+
+	err = plan.Apply(importSkipExistingFlag)
+	if err != nil {
+		slog.Error("failed to import", "error", err)
+		os.Exit(1)
+	}
+}
+
+// lists what will happen to each value
+func importPreview(plan polyenvfile.ImportPlan, skipExisting bool) *list.List {
+	changedTitle := "overwrite (value differs)"
+	if skipExisting {
+		changedTitle = "skip (value differs, --skip-existing)"
+	}
+	groups := []struct {
+		status polyenvfile.ImportStatus
+		title  string
+	}{
+		{polyenvfile.ImportNew, "new"},
+		{polyenvfile.ImportChanged, changedTitle},
+		{polyenvfile.ImportUnchanged, "unchanged"},
+	}
+
+	li := list.New()
+	for _, grp := range groups {
+		items := plan.Filter(grp.status)
+		if len(items) == 0 {
+			continue
+		}
+		sub := list.New()
+		for _, item := range items {
+			line := fmt.Sprintf("%s -> %s", item.Env.Key, item.Env.File)
+			if item.Env.IsSecret {
+				line += fmt.Sprintf(" (secret: %s)", item.SecretReason)
+			}
+			sub.Item(line)
+		}
+		li.Items(fmt.Sprintf("%s (%d)", grp.title, len(items)), sub)
+	}
+	return li
+}
+
+// reads the env files of another environment. '!{env}'
+type envFilesSource struct{}
+
+func (s *envFilesSource) Name() string {
+	return "polyenv"
+}
+
+func (s *envFilesSource) Detect(arg string) bool {
+	return strings.HasPrefix(arg, "!")
+}
+
+func (s *envFilesSource) Get(arg string) ([]model.StoredEnv, error) {
+	env := strings.TrimPrefix(arg, "!")
+	if env == Environment {
+		return nil, fmt.Errorf("cannot import !%s into itself", env)
+	}
+	file, err := polyenvfile.OpenFile(env)
+	if err != nil {
+		return nil, err
+	}
+	return file.AllDotenvValues()
+}
@@ -6,6 +6,7 @@ package cmd
 import (
 	"fmt"
 	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
 	"time"
@@ -24,6 +25,7 @@ func generatePullCommand() *cobra.Command {
 		pull all secrets from vault.
 		if option.use dot secret file is enabled it will be set in .env.secret file
 		if not, it will try to find a key set in any of your .env files. if it cannot find it, it will error out.
+		vault references in .env files (KEY=polyenv://{vault}/{key}) are always written to the .env.secret file, so the reference is kept.
 	`,
 		Run: pull,
 	}
@@ -42,12 +44,37 @@ func pull(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	refs, err := PolyenvFile.VaultReferences()
+	if err != nil {
+		slog.Error("failed to get vault references", "error", err)
+		os.Exit(1)
+	}
+	secrets := maps.Clone(PolyenvFile.Secrets)
+	if secrets == nil {
+		secrets = make(map[string]model.Secret)
+	}
+	for k, v := range refs {
+		if _, ok := secrets[k]; ok {
+			slog.Warn("key is both a secret in the polyenv file and a vault reference, using the polyenv file", "key", k)
+			delete(refs, k)
+			continue
+		}
+		secrets[k] = v
+	}
+
 	//region pull:precheck
 	//precheck. if not using .secret file, check if key exists in existing dotenv file
 	// TODO: Is this neccessary? how can we know where to create the env key-val pair?
 	slog.Debug("precheck!", "use dot secret file", PolyenvFile.Options.UseDotSecretFileForSecrets)
 	if !PolyenvFile.Options.UseDotSecretFileForSecrets {
-		for k := range PolyenvFile.Secrets {
+		keys := make([]string, 0, len(PolyenvFile.Secrets))
+		for k, v := range PolyenvFile.Secrets {
+			keys = append(keys, k)
+			if v.KeyLocalKey != "" {
+				keys = append(keys, v.KeyLocalKey)
+			}
+		}
+		for _, k := range keys {
 			matches := 0
 			for _, f := range existingEnv {
 				if f.Key == k {
@@ -62,7 +89,8 @@ func pull(cmd *cobra.Command, args []string) {
 				os.Exit(1)
 			}
 		}
-	} else {
+	}
+	if PolyenvFile.Options.UseDotSecretFileForSecrets || len(refs) > 0 {
 		root, e := tools.GetGitRootOrCwd()
 		if e != nil {
 			slog.Error("failed to get project root", "error", e)
@@ -92,13 +120,13 @@ func pull(cmd *cobra.Command, args []string) {
 	//region pull:from vaults
 	cnt := 0
 	contents := make([]model.StoredEnv, 0)
-	for k, v := range PolyenvFile.Secrets {
+	for k, v := range secrets {
 		cnt++
 		spn := spinner.Points
 		spn.FPS = time.Second / 15
 		sp := spinner.New().Title(k + " pulling").Type(spn)
 		err := sp.Action(func() {
-			prefix := fmt.Sprintf(" %d/%d - %s -> ", cnt, len(PolyenvFile.Secrets), k)
+			prefix := fmt.Sprintf(" %d/%d - %s -> ", cnt, len(secrets), k)
 			// get vault from file
 			slog.Debug("pulling", "secret", v.RemoteKey, "vault", v.Vault)
 			sp = sp.Title(prefix + " getting local vault definition")
@@ -118,7 +146,7 @@ func pull(cmd *cobra.Command, args []string) {
 
 			// elevate permissions
 			sp = sp.Title(prefix + " elevating permissions to " + v.Vault)
-			err = vlt.PullElevate()
+			err = vlt.PullElevate(cmd.Context())
 			if err != nil {
 				slog.Error("failed to elevate permissions", "vault", v.Vault, "error", err)
 				os.Exit(1)
@@ -131,10 +159,13 @@ func pull(cmd *cobra.Command, args []string) {
 				slog.Error("failed to pull secret", "vault", v.Vault, "error", err)
 				os.Exit(1)
 			}
-			contents = append(contents, model.StoredEnv{
-				Value: content.Value,
-				Key:   v.LocalKey,
-			})
+			slog.Debug("pulled secret", "secret", v.RemoteKey, "version", content.Version, "expires", content.Expires, "tags", content.Tags)
+			envs, err := secretToEnvs(v, content)
+			if err != nil {
+				slog.Error("failed to handle pulled secret", "secret", v.RemoteKey, "error", err)
+				os.Exit(1)
+			}
+			contents = append(contents, envs...)
 		}).Run()
 		if err != nil {
 			slog.Error("failed to run spinner", "error", err)
@@ -144,7 +175,8 @@ func pull(cmd *cobra.Command, args []string) {
 
 	//region pull:write files
 	for _, newEnv := range contents {
-		if PolyenvFile.Options.UseDotSecretFileForSecrets {
+		_, isRef := refs[newEnv.Key]
+		if PolyenvFile.Options.UseDotSecretFileForSecrets || isRef {
 			slog.Debug("writing to .env.secret", "key", newEnv.Key, "file", secretFilePath)
 			// slog.Info("writing to .env.secret", "key", newEnv.Key, "file", secretFilePath)
 			newEnv.File = secretFilePath
@@ -171,3 +203,46 @@ func pull(cmd *cobra.Command, args []string) {
 	}
 
 }
+
+// converts pulled content to env values.
+// if key_local_key is set the pem value is split into certificate and private key.
+// if path is set the value is written to file and the env value becomes the path
+func secretToEnvs(s model.Secret, content model.SecretContent) ([]model.StoredEnv, error) {
+	if s.Path != "" && s.KeyLocalKey != "" {
+		return nil, fmt.Errorf("path and key_local_key cannot be used together")
+	}
+
+	if s.Path != "" {
+		path := s.Path
+		if !filepath.IsAbs(path) {
+			root, err := tools.GetGitRootOrCwd()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get project root: %w", err)
+			}
+			path = filepath.Join(root, path)
+		}
+		// path can be in a directory that does not exist yet, and an existing file has to end up as 0600
+		err := tools.WriteFileAtomic(path, []byte(content.Value), 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		slog.Debug("wrote secret to file", "secret", s.RemoteKey, "path", path)
+		return []model.StoredEnv{{Key: s.LocalKey, Value: path}}, nil
+	}
+
+	if s.KeyLocalKey != "" {
+		certs, key, err := model.SplitPEM(content.Value)
+		if err != nil {
+			return nil, err
+		}
+		if key == "" {
+			return nil, fmt.Errorf("key_local_key is set, but %s does not contain a private key", s.RemoteKey)
+		}
+		return []model.StoredEnv{
+			{Key: s.LocalKey, Value: certs},
+			{Key: s.KeyLocalKey, Value: key},
+		}, nil
+	}
+
+	return []model.StoredEnv{{Key: s.LocalKey, Value: content.Value}}, nil
+}
@@ -46,6 +46,7 @@ func init() {
 		cmd.AddCommand(generateAddCommand())
 		cmd.AddCommand(generatePullCommand())
 		cmd.AddCommand(generateEnvCommand())
+		cmd.AddCommand(generateImportCommand())
 
 		rootCmd.AddCommand(cmd)
 	}
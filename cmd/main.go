@@ -4,12 +4,15 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
+	"os/signal"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+	"github.com/withholm/polyenv/internal/model"
 	"github.com/withholm/polyenv/internal/tools"
 )
 
@@ -23,6 +26,9 @@ var Debug bool
 // some other thing
 var DisableTruncateDebug bool
 
+// redact secrets in output. 'full', 'last:N' or 'hash'
+var RedactFlag string
+
 var (
 	// These variables are populated by the Go linker during the build process.
 	version = "dev"
@@ -77,6 +83,8 @@ func init() {
 	// add persistend flags (flags that are set for all commands)
 	rootCmd.PersistentFlags().BoolVar(&Debug, "debug", false, "Enable debug logging")
 	rootCmd.PersistentFlags().BoolVar(&DisableTruncateDebug, "disable-truncate-debug", false, "dont truncate debug logging")
+	rootCmd.PersistentFlags().StringVar(&RedactFlag, "redact", "", "hide secrets in output: '--redact=full', '--redact=last:N' (show last N characters) or '--redact=hash'. the mode needs '=', '--redact' alone is 'full'")
+	rootCmd.PersistentFlags().Lookup("redact").NoOptDefVal = string(model.RedactFull)
 	// add version command
 
 	rootCmd.AddCommand(versionCmd)
@@ -85,7 +93,16 @@ func init() {
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	// ctrl-c cancels the context of the command, so long waits (ie pim activation) can stop.
+	// after the first interrupt the default handling is restored, so a second one exits right away
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
@@ -53,7 +53,7 @@ func status(cmd *cobra.Command, args []string) {
 				if secVal.Vault != k {
 					continue
 				}
-				secList.Item(fmt.Sprintf("secret %s -> %s (%s)", secVal.RemoteKey, secKey, secVal.ContentType))
+				secList.Item(fmt.Sprintf("secret %s -> %s", secVal.ToString(), secKey))
 			}
 
 			slog.Debug("vault", "name", k, "vault", v.String())
@@ -0,0 +1,238 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package model
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// dotenv files are read and written raw: references to other keys (${VAR}) are kept as is,
+// so they can be expanded over all env files later (godotenv only expands within a file).
+// in a raw value a literal $ is written as $$. use EscapeRaw and UnescapeRaw to convert
+
+// reads a dotenv file without expanding references. see ParseDotenv
+func ReadDotenv(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	out, err := ParseDotenv(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return out, nil
+}
+
+// parses dotenv with the same syntax as godotenv, but returns raw values.
+// \$ and $$ in unquoted and double quoted values and any $ in single quoted values are literal,
+// so values written by hand read the same as with godotenv
+func ParseDotenv(data []byte) (map[string]string, error) {
+	src := strings.ReplaceAll(string(data), "\r\n", "\n")
+	out := make(map[string]string)
+	for {
+		src = skipDotenvComments(src)
+		if src == "" {
+			return out, nil
+		}
+		key, rest, err := parseDotenvKey(src)
+		if err != nil {
+			return nil, err
+		}
+		value, rest, err := parseDotenvValue(rest)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", key, err)
+		}
+		out[key] = value
+		src = rest
+	}
+}
+
+// writes raw values to a dotenv file. references are kept, literal $ are escaped
+func WriteDotenv(values map[string]string, path string) error {
+	lines := make([]string, 0, len(values))
+	for k, v := range values {
+		if d, err := strconv.Atoi(v); err == nil && strconv.Itoa(d) == v {
+			lines = append(lines, k+"="+v)
+			continue
+		}
+		lines = append(lines, k+`="`+escapeDotenvRaw(v)+`"`)
+	}
+	sort.Strings(lines)
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644)
+}
+
+// converts a value to a raw value, so $ is not read as a reference
+func EscapeRaw(value string) string {
+	return strings.ReplaceAll(value, "$", "$$")
+}
+
+// converts a raw value back to text, keeping references as written
+func UnescapeRaw(raw string) string {
+	return strings.ReplaceAll(raw, "$$", "$")
+}
+
+func skipDotenvComments(src string) string {
+	for {
+		src = strings.TrimLeftFunc(src, unicode.IsSpace)
+		if !strings.HasPrefix(src, "#") {
+			return src
+		}
+		_, rest, ok := strings.Cut(src, "\n")
+		if !ok {
+			return ""
+		}
+		src = rest
+	}
+}
+
+func parseDotenvKey(src string) (key string, rest string, err error) {
+	src = strings.TrimLeftFunc(src, isDotenvSpace)
+	if trimmed, ok := strings.CutPrefix(src, "export"); ok && strings.IndexFunc(trimmed, isDotenvSpace) == 0 {
+		src = strings.TrimLeftFunc(trimmed, isDotenvSpace)
+	}
+
+	for i, r := range src {
+		switch {
+		case r == '=' || r == ':':
+			key = strings.TrimRightFunc(src[:i], unicode.IsSpace)
+			if key == "" {
+				return "", "", fmt.Errorf("missing key name near %q", firstLine(src))
+			}
+			return key, strings.TrimLeftFunc(src[i+1:], isDotenvSpace), nil
+		case isDotenvSpace(r), r == '_', r == '.', unicode.IsLetter(r), unicode.IsNumber(r):
+			continue
+		default:
+			return "", "", fmt.Errorf("unexpected character %q in key near %q", r, firstLine(src))
+		}
+	}
+	return "", "", fmt.Errorf("missing value for %q", firstLine(src))
+}
+
+func parseDotenvValue(src string) (value string, rest string, err error) {
+	if src == "" || (src[0] != '"' && src[0] != '\'') {
+		end := strings.IndexAny(src, "\r\n")
+		if end == -1 {
+			end = len(src)
+		}
+		line := src[:end]
+		// a comment has to be preceded by a space, so 'a#b' is a value
+		if i := max(strings.LastIndex(line, " #"), strings.LastIndex(line, "\t#")); i != -1 {
+			line = line[:i]
+		}
+		line = strings.TrimFunc(line, isDotenvSpace)
+		return rawDollars(line), src[end:], nil
+	}
+
+	quote := src[0]
+	for i := 1; i < len(src); i++ {
+		if src[i] != quote {
+			continue
+		}
+		// skip escaped quotes, but not an escaped backslash before the quote
+		escapes := 0
+		for j := i - 1; j > 0 && src[j] == '\\'; j-- {
+			escapes++
+		}
+		if escapes%2 == 1 {
+			continue
+		}
+		content := src[1:i]
+		if quote == '\'' {
+			return EscapeRaw(content), src[i+1:], nil
+		}
+		return unescapeDotenvDouble(content), src[i+1:], nil
+	}
+	return "", "", fmt.Errorf("unterminated quoted value %s", firstLine(src))
+}
+
+// converts an unquoted value to a raw value: \$ and $$ are literal
+func rawDollars(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == '$':
+			b.WriteString("$$")
+			i++
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '$':
+			b.WriteString("$$$$")
+			i++
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// handles escapes in double quotes: \n, \r, \$ (literal $) and \x -> x. $$ is literal too
+func unescapeDotenvDouble(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '$' && i+1 < len(s) && s[i+1] == '$' {
+			b.WriteString("$$$$")
+			i++
+			continue
+		}
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case '$':
+			b.WriteString("$$")
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// escapes a raw value for double quotes. same characters as godotenv, but references are kept
+func escapeDotenvRaw(raw string) string {
+	var b strings.Builder
+	for i := 0; i < len(raw); i++ {
+		switch c := raw[i]; c {
+		case '$':
+			if i+1 < len(raw) && raw[i+1] == '$' {
+				b.WriteString(`\$`)
+				i++
+				continue
+			}
+			b.WriteByte('$')
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\\', '"', '!', '`':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// same as godotenv: a space, but not a line break
+func isDotenvSpace(r rune) bool {
+	switch r {
+	case '\t', '\v', '\f', '\r', ' ', 0x85, 0xA0:
+		return true
+	}
+	return false
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
@@ -0,0 +1,102 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package model
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/joho/godotenv"
+)
+
+func TestParseDotenv(t *testing.T) {
+	testCases := []struct {
+		name      string
+		input     string
+		expected  map[string]string
+		expectErr bool
+	}{
+		{name: "plain", input: "A=1\nB = two\n", expected: map[string]string{"A": "1", "B": "two"}},
+		{name: "comments and export", input: "# comment\nexport A=1 # trailing\n\nB=a#b\n", expected: map[string]string{"A": "1", "B": "a#b"}},
+		{name: "yaml style", input: "A: 1\n", expected: map[string]string{"A": "1"}},
+		{name: "reference", input: "A=${B}\nB=\"x-${C:-d}\"\n", expected: map[string]string{"A": "${B}", "B": "x-${C:-d}"}},
+		{name: "escaped dollar", input: "A=\\$B\nB=\"\\$C\"\n", expected: map[string]string{"A": "$$B", "B": "$$C"}},
+		{name: "double dollar is literal", input: "A=pa$$word\nB=\"$$${C}\"\n", expected: map[string]string{"A": "pa$$$$word", "B": "$$$$${C}"}},
+		{name: "single quotes are literal", input: "A='${B}\\n'\n", expected: map[string]string{"A": "$${B}\\n"}},
+		{name: "double quote escapes", input: "A=\"a\\nb\\\"c\\\\\"\n", expected: map[string]string{"A": "a\nb\"c\\"}},
+		{name: "multiline", input: "A=\"a\nb\"\nB=1\n", expected: map[string]string{"A": "a\nb", "B": "1"}},
+		{name: "empty", input: "A=\nB=\"\"\n", expected: map[string]string{"A": "", "B": ""}},
+		{name: "unterminated", input: "A=\"abc\n", expectErr: true},
+		{name: "bad key", input: "A-B=1\n", expectErr: true},
+		{name: "missing value", input: "A\n", expectErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseDotenv([]byte(tc.input))
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDotenv() returned an error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+// values without references has to be read the same way as godotenv does
+func TestParseDotenvMatchesGodotenv(t *testing.T) {
+	input := "A=1\nexport B='single # not comment'\nC=\"multi\nline\"\nD=value # comment\nE: yaml\nF=\"esc\\\"aped\\n\"\nG=pa$$word\nH=\"a$$b\"\n"
+	expected, err := godotenv.Unmarshal(input)
+	if err != nil {
+		t.Fatalf("godotenv failed: %v", err)
+	}
+	got, err := ParseDotenv([]byte(input))
+	if err != nil {
+		t.Fatalf("ParseDotenv() returned an error: %v", err)
+	}
+	for k, v := range got {
+		got[k] = UnescapeRaw(v)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestWriteDotenv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	values := map[string]string{
+		"REF":     "postgres://${USER}:${PASSWORD:-x}@host",
+		"LITERAL": EscapeRaw("pa$word \"quoted\"\n!"),
+		"NUMBER":  "42",
+		"PADDED":  "007",
+	}
+	err := WriteDotenv(values, path)
+	if err != nil {
+		t.Fatalf("WriteDotenv() returned an error: %v", err)
+	}
+
+	got, err := ReadDotenv(path)
+	if err != nil {
+		t.Fatalf("ReadDotenv() returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(got, values) {
+		t.Errorf("expected %q, got %q", values, got)
+	}
+
+	// literal values has to stay readable by godotenv
+	plain, err := godotenv.Read(path)
+	if err != nil {
+		t.Fatalf("godotenv failed: %v", err)
+	}
+	if plain["LITERAL"] != "pa$word \"quoted\"\n!" {
+		t.Errorf("unexpected value read by godotenv: %q", plain["LITERAL"])
+	}
+}
@@ -0,0 +1,111 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package model
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// selects and renames values before they are formatted.
+// filters are applied in order: files, include, exclude, secrets, strip prefix and prefix
+type ExportFilter struct {
+	//glob patterns on keys. a value is kept if it matches any of them. case insensitive
+	Include []string
+	//glob patterns on keys. a value is removed if it matches any of them. case insensitive
+	Exclude []string
+	//only keep secrets (IsSecret or detected)
+	OnlySecrets bool
+	//remove secrets (IsSecret or detected)
+	NoSecrets bool
+	//glob patterns on the file a value comes from, matched on the full path and the file name
+	Files []string
+	//removed from the start of keys that have it
+	StripPrefix string
+	//added to the start of all keys
+	Prefix string
+}
+
+func (f ExportFilter) Validate() error {
+	if f.OnlySecrets && f.NoSecrets {
+		return fmt.Errorf("only secrets and no secrets cannot be used together")
+	}
+	for _, p := range append(append(append([]string{}, f.Include...), f.Exclude...), f.Files...) {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid pattern '%s': %w", p, err)
+		}
+	}
+	return nil
+}
+
+// returns the values that passes the filter, renamed. fails if two different keys end up the same after renaming
+func (f ExportFilter) Apply(data []StoredEnv) ([]StoredEnv, error) {
+	err := f.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]StoredEnv, 0, len(data))
+	renamed := make(map[string]string)
+	for _, v := range data {
+		if len(f.Files) > 0 && !matchAny(f.Files, v.File, filepath.Base(v.File)) {
+			continue
+		}
+		key := strings.ToUpper(v.Key)
+		if len(f.Include) > 0 && !matchAny(upperAll(f.Include), key) {
+			continue
+		}
+		if matchAny(upperAll(f.Exclude), key) {
+			continue
+		}
+		if f.OnlySecrets || f.NoSecrets {
+			secret := v.IsSecret
+			if !secret {
+				secret, _ = v.DetectSecret()
+			}
+			if (f.OnlySecrets && !secret) || (f.NoSecrets && secret) {
+				continue
+			}
+		}
+
+		original := v.Key
+		if f.StripPrefix != "" {
+			v.Key = strings.TrimPrefix(v.Key, f.StripPrefix)
+			if v.Key == "" {
+				return nil, fmt.Errorf("key %s is empty after removing prefix %s", original, f.StripPrefix)
+			}
+		}
+		v.Key = f.Prefix + v.Key
+		// the same key in multiple files is fine, two keys renamed to the same is not
+		if existing, ok := renamed[v.Key]; ok && existing != original {
+			return nil, fmt.Errorf("both %s and %s becomes %s", existing, original, v.Key)
+		}
+		renamed[v.Key] = original
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+// true if any of the values matches any of the patterns
+func matchAny(patterns []string, values ...string) bool {
+	for _, p := range patterns {
+		for _, v := range values {
+			// patterns are validated before use
+			if ok, _ := path.Match(p, v); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func upperAll(s []string) []string {
+	out := make([]string, len(s))
+	for i, v := range s {
+		out[i] = strings.ToUpper(v)
+	}
+	return out
+}
@@ -0,0 +1,74 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package model
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExportFilter(t *testing.T) {
+	data := []StoredEnv{
+		{Key: "APP_NAME", Value: "polyenv", File: "/repo/.env.dev"},
+		{Key: "APP_DB_HOST", Value: "localhost", File: "/repo/.env.dev"},
+		{Key: "APP_DB_PASSWORD", Value: "hunter2", File: "/repo/.env.secret.dev"},
+		{Key: "OTHER", Value: "x", File: "/repo/sub/.env.dev", IsSecret: true},
+	}
+
+	testCases := []struct {
+		name      string
+		filter    ExportFilter
+		expected  []string
+		expectErr bool
+	}{
+		{name: "no filter", filter: ExportFilter{}, expected: []string{"APP_NAME", "APP_DB_HOST", "APP_DB_PASSWORD", "OTHER"}},
+		{name: "include", filter: ExportFilter{Include: []string{"app_db_*"}}, expected: []string{"APP_DB_HOST", "APP_DB_PASSWORD"}},
+		{name: "include and exclude", filter: ExportFilter{Include: []string{"APP_*"}, Exclude: []string{"*PASSWORD"}}, expected: []string{"APP_NAME", "APP_DB_HOST"}},
+		{name: "only secrets", filter: ExportFilter{OnlySecrets: true}, expected: []string{"APP_DB_PASSWORD", "OTHER"}},
+		{name: "no secrets", filter: ExportFilter{NoSecrets: true}, expected: []string{"APP_NAME", "APP_DB_HOST"}},
+		{name: "file name", filter: ExportFilter{Files: []string{".env.secret.*"}}, expected: []string{"APP_DB_PASSWORD"}},
+		{name: "file path", filter: ExportFilter{Files: []string{"/repo/sub/*"}}, expected: []string{"OTHER"}},
+		{name: "strip prefix", filter: ExportFilter{Include: []string{"APP_*"}, StripPrefix: "APP_"}, expected: []string{"NAME", "DB_HOST", "DB_PASSWORD"}},
+		{name: "strip and prefix", filter: ExportFilter{StripPrefix: "APP_", Prefix: "MY_"}, expected: []string{"MY_NAME", "MY_DB_HOST", "MY_DB_PASSWORD", "MY_OTHER"}},
+		{name: "include after strip", filter: ExportFilter{StripPrefix: "APP_DB_", Include: []string{"*HOST"}}, expected: []string{"HOST"}},
+		{name: "both secret filters", filter: ExportFilter{OnlySecrets: true, NoSecrets: true}, expectErr: true},
+		{name: "bad pattern", filter: ExportFilter{Include: []string{"["}}, expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := tc.filter.Apply(data)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Apply() returned an error: %v", err)
+			}
+			var keys []string
+			for _, v := range out {
+				keys = append(keys, v.Key)
+			}
+			if !slices.Equal(keys, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, keys)
+			}
+		})
+	}
+
+	_, err := ExportFilter{StripPrefix: "APP_DB_"}.Apply([]StoredEnv{{Key: "APP_DB_HOST"}, {Key: "HOST"}})
+	if err == nil {
+		t.Error("expected error when keys collide after renaming")
+	}
+
+	// same key from multiple files
+	out, err := ExportFilter{Prefix: "X_"}.Apply([]StoredEnv{{Key: "A", File: "a"}, {Key: "A", File: "b"}})
+	if err != nil {
+		t.Fatalf("Apply() returned an error on duplicate keys: %v", err)
+	}
+	if len(out) != 2 {
+		t.Errorf("expected both values to be kept, got %d", len(out))
+	}
+}
@@ -0,0 +1,39 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package model
+
+import (
+	"encoding/pem"
+	"fmt"
+	"strings"
+)
+
+// SplitPEM splits a pem bundle into certificate(s) and private key.
+// other blocks (ie public keys) are returned together with the certificates
+func SplitPEM(value string) (certs string, key string, err error) {
+	rest := []byte(value)
+	var certBlocks, keyBlocks []string
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		encoded := string(pem.EncodeToMemory(block))
+		if strings.HasSuffix(block.Type, "PRIVATE KEY") {
+			keyBlocks = append(keyBlocks, encoded)
+			continue
+		}
+		certBlocks = append(certBlocks, encoded)
+	}
+
+	if len(certBlocks) == 0 && len(keyBlocks) == 0 {
+		return "", "", fmt.Errorf("value does not contain any pem blocks")
+	}
+	if len(keyBlocks) > 1 {
+		return "", "", fmt.Errorf("value contains %d private keys, expected at most one", len(keyBlocks))
+	}
+
+	return strings.Join(certBlocks, ""), strings.Join(keyBlocks, ""), nil
+}
@@ -3,10 +3,13 @@
 
 package model
 
+// read env values from a source (stdin, file, env etc)
 type Source interface {
 	Name() string
-	Detect(args []byte) bool
-	Get(args []byte, file string) error
+	//detect if the source can handle the given argument (ie a path or url)
+	Detect(arg string) bool
+	//read values from the source
+	Get(arg string) ([]StoredEnv, error)
 }
 
 // convert secret to output bytes
@@ -37,3 +40,10 @@ type Writer interface {
 	//write to a writer
 	Write([]byte) error
 }
+
+// optional for writers that need the values behind the formatted data, ie to mask secrets in ci logs
+type EnvAwareWriter interface {
+	Writer
+	//called with the values before Write
+	SetEnv([]StoredEnv)
+}
@@ -0,0 +1,96 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type RedactMode string
+
+const (
+	RedactNone RedactMode = ""
+	// replace the whole value
+	RedactFull RedactMode = "full"
+	// show the last n characters
+	RedactLast RedactMode = "last"
+	// show a short sha256 of the value, so values can be compared without showing them
+	RedactHash RedactMode = "hash"
+)
+
+const redactMask = "********"
+
+// hides secret values, so output can be shared
+type Redact struct {
+	Mode RedactMode
+	//number of characters shown with RedactLast
+	Last int
+}
+
+// parses 'full', 'hash', 'last' (last 4) or 'last:N'. empty means no redaction
+func ParseRedact(s string) (Redact, error) {
+	mode, arg, hasArg := strings.Cut(strings.ToLower(strings.TrimSpace(s)), ":")
+	switch RedactMode(mode) {
+	case RedactNone:
+		return Redact{}, nil
+	case RedactFull, RedactHash:
+		if hasArg {
+			return Redact{}, fmt.Errorf("redact mode '%s' does not take an argument", mode)
+		}
+		return Redact{Mode: RedactMode(mode)}, nil
+	case RedactLast:
+		r := Redact{Mode: RedactLast, Last: 4}
+		if hasArg {
+			n, err := strconv.Atoi(arg)
+			if err != nil || n < 1 {
+				return Redact{}, fmt.Errorf("redact 'last:N' needs a positive number, got '%s'", arg)
+			}
+			r.Last = n
+		}
+		return r, nil
+	}
+	return Redact{}, fmt.Errorf("unknown redact mode '%s'. use 'full', 'last', 'last:N' or 'hash'", s)
+}
+
+// redacts a single value
+func (r Redact) Value(value string) string {
+	switch r.Mode {
+	case RedactFull:
+		return redactMask
+	case RedactLast:
+		runes := []rune(value)
+		// showing the whole (or most of) a short value defeats the purpose
+		if len(runes) <= r.Last*2 {
+			return redactMask
+		}
+		return redactMask + string(runes[len(runes)-r.Last:])
+	case RedactHash:
+		sum := sha256.Sum256([]byte(value))
+		return "sha256:" + hex.EncodeToString(sum[:])[:12]
+	}
+	return value
+}
+
+// returns a copy of data with secrets (IsSecret or detected) redacted
+func (r Redact) Apply(data []StoredEnv) []StoredEnv {
+	out := make([]StoredEnv, len(data))
+	copy(out, data)
+	if r.Mode == RedactNone {
+		return out
+	}
+	for i, v := range out {
+		secret := v.IsSecret
+		if !secret {
+			secret, _ = v.DetectSecret()
+		}
+		if secret && v.Value != "" {
+			out[i].Value = r.Value(v.Value)
+		}
+	}
+	return out
+}
@@ -0,0 +1,74 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package model
+
+import (
+	"testing"
+)
+
+func TestParseRedact(t *testing.T) {
+	testCases := []struct {
+		in        string
+		expected  Redact
+		expectErr bool
+	}{
+		{in: "", expected: Redact{}},
+		{in: "full", expected: Redact{Mode: RedactFull}},
+		{in: "HASH", expected: Redact{Mode: RedactHash}},
+		{in: "last", expected: Redact{Mode: RedactLast, Last: 4}},
+		{in: "last:2", expected: Redact{Mode: RedactLast, Last: 2}},
+		{in: "last:0", expectErr: true},
+		{in: "full:2", expectErr: true},
+		{in: "nope", expectErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.in, func(t *testing.T) {
+			r, err := ParseRedact(tc.in)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRedact() returned an error: %v", err)
+			}
+			if r != tc.expected {
+				t.Errorf("expected %+v, got %+v", tc.expected, r)
+			}
+		})
+	}
+}
+
+func TestRedactApply(t *testing.T) {
+	data := []StoredEnv{
+		{Key: "USERNAME", Value: "testuser"},
+		{Key: "TOKEN", Value: "abcdefghij", IsSecret: true},
+		{Key: "DB_PASSWORD", Value: "short"},
+	}
+
+	testCases := []struct {
+		name     string
+		redact   Redact
+		expected []string
+	}{
+		{name: "none", redact: Redact{}, expected: []string{"testuser", "abcdefghij", "short"}},
+		{name: "full", redact: Redact{Mode: RedactFull}, expected: []string{"testuser", "********", "********"}},
+		{name: "last", redact: Redact{Mode: RedactLast, Last: 4}, expected: []string{"testuser", "********ghij", "********"}},
+		{name: "hash", redact: Redact{Mode: RedactHash}, expected: []string{"testuser", "sha256:72399361da6a", "sha256:f9b0078b5df5"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			out := tc.redact.Apply(data)
+			for i, v := range out {
+				if v.Value != tc.expected[i] {
+					t.Errorf("%s: expected %s, got %s", v.Key, tc.expected[i], v.Value)
+				}
+			}
+			if data[1].Value != "abcdefghij" {
+				t.Error("Apply() changed the input")
+			}
+		})
+	}
+}
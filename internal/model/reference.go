@@ -0,0 +1,40 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// prefix of a dotenv value that references a secret in a vault: polyenv://{vault}/{remote key}
+const VaultReferencePrefix = "polyenv://"
+
+// returns true if the value is a reference to a secret in a vault
+func IsVaultReference(value string) bool {
+	return strings.HasPrefix(value, VaultReferencePrefix)
+}
+
+// creates a vault reference value for a secret
+func VaultReference(vault string, remoteKey string) string {
+	return VaultReferencePrefix + vault + "/" + remoteKey
+}
+
+// parses a value like polyenv://{vault}/{remote key} to a secret. localKey is the key the value is set on.
+// returns false if the value is not a vault reference
+func ParseVaultReference(localKey string, value string) (Secret, bool, error) {
+	ref, ok := strings.CutPrefix(value, VaultReferencePrefix)
+	if !ok {
+		return Secret{}, false, nil
+	}
+	vault, remoteKey, _ := strings.Cut(ref, "/")
+	if vault == "" || remoteKey == "" {
+		return Secret{}, true, fmt.Errorf("invalid vault reference %s: expected %s{vault}/{key}", value, VaultReferencePrefix)
+	}
+	return Secret{
+		Vault:     vault,
+		RemoteKey: remoteKey,
+		LocalKey:  localKey,
+	}, true, nil
+}
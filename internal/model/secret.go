@@ -4,14 +4,14 @@
 package model
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"math"
 	"os"
 	"regexp"
 	"strings"
-
-	"github.com/joho/godotenv"
+	"time"
 )
 
 type Secret struct {
@@ -20,6 +20,19 @@ type Secret struct {
 	Enabled     bool   `toml:"-"`
 	RemoteKey   string `toml:"remote_key"`
 	LocalKey    string `toml:"-"`
+	//pin the secret to a specific version. empty means latest
+	Version string `toml:"version,omitempty"`
+	//type of object in the vault. empty means secret. vaults may support more (ie 'certificate' and 'key')
+	Type string `toml:"type,omitempty"`
+	//store the private key of a pem value in this local key. the certificate(s) are stored in LocalKey
+	KeyLocalKey string `toml:"key_local_key,omitempty"`
+	//write the value to this file instead. LocalKey will be set to the path of the file
+	Path string `toml:"path,omitempty"`
+
+	//metadata from vault. not stored in polyenv file.
+	//keep the struct comparable, it is used as a value in tui selects
+	Expires   *time.Time `toml:"-"`
+	NotBefore *time.Time `toml:"-"`
 }
 
 // Used when pushing or pulling secrets
@@ -28,6 +41,13 @@ type SecretContent struct {
 	Value       string
 	RemoteKey   string
 	LocalKey    string
+	Version     string
+
+	//metadata returned by vault on pull
+	Enabled   bool
+	Expires   *time.Time
+	NotBefore *time.Time
+	Tags      map[string]string
 }
 
 // data coming from dotenv file
@@ -44,11 +64,16 @@ type InputEnv struct {
 	String string
 }
 
-func (s Secret) ToString() string { return fmt.Sprintf("%s (%s)", s.RemoteKey, s.ContentType) }
+func (s Secret) ToString() string {
+	if s.Version != "" {
+		return fmt.Sprintf("%s@%s (%s)", s.RemoteKey, s.Version, s.ContentType)
+	}
+	return fmt.Sprintf("%s (%s)", s.RemoteKey, s.ContentType)
+}
 
 // Gets the secret content from the vault
-func (s Secret) GetContent(v Vault) (string, error) {
-	err := v.PullElevate()
+func (s Secret) GetContent(ctx context.Context, v Vault) (string, error) {
+	err := v.PullElevate(ctx)
 	if err != nil {
 		return "", fmt.Errorf("failed to elevate permissions: %w", err)
 	}
@@ -61,8 +86,8 @@ func (s Secret) GetContent(v Vault) (string, error) {
 }
 
 // Pushes the secret content to the vault
-func (s Secret) SetContent(v Vault, content SecretContent) error {
-	err := v.PushElevate()
+func (s Secret) SetContent(ctx context.Context, v Vault, content SecretContent) error {
+	err := v.PushElevate(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to elevate permissions: %w", err)
 	}
@@ -75,22 +100,23 @@ func (s Secret) SetContent(v Vault, content SecretContent) error {
 }
 
 // saves stored env variable to dotenv file.
-// will update if key already exists, or create new if not
+// will update if key already exists, or create new if not. references in other keys are kept
 func (st StoredEnv) Save() error {
-	mp, e := godotenv.Read(st.File)
+	mp, e := ReadDotenv(st.File)
 	if e != nil {
 		return fmt.Errorf("failed to parse dotenv file: %w", e)
 	}
+	value := EscapeRaw(st.Value)
 	currentvalue, ok := mp[st.Key]
 	if ok {
-		if currentvalue == st.Value {
+		if currentvalue == value {
 			return nil
 		}
 	}
 
-	mp[st.Key] = st.Value
+	mp[st.Key] = value
 	slog.Debug("saving env", "key", st.Key, "file", st.File)
-	e = godotenv.Write(mp, st.File)
+	e = WriteDotenv(mp, st.File)
 	if e != nil {
 		return fmt.Errorf("failed to write to dotenv file: %w", e)
 	}
@@ -99,7 +125,7 @@ func (st StoredEnv) Save() error {
 
 // removes stored env variable from dotenv file
 func (st StoredEnv) Remove() error {
-	mp, e := godotenv.Read(st.File)
+	mp, e := ReadDotenv(st.File)
 	if e != nil {
 		if os.IsNotExist(e) {
 			return nil
@@ -112,7 +138,7 @@ func (st StoredEnv) Remove() error {
 		delete(mp, st.Key)
 	}
 
-	e = godotenv.Write(mp, st.File)
+	e = WriteDotenv(mp, st.File)
 	if e != nil {
 		return fmt.Errorf("failed to write to dotenv file: %w", e)
 	}
@@ -168,6 +194,11 @@ func (st StoredEnv) DetectSecret() (bool, string) {
 		return false, ""
 	}
 
+	// 0. the value is pulled from a vault
+	if IsVaultReference(st.Value) {
+		return true, "Vault reference"
+	}
+
 	// 1. Key Name Analysis
 	upperKey := strings.ToUpper(st.Key)
 	for _, keyword := range secretKeywords {
@@ -4,7 +4,11 @@
 // Package model contains all models for polyenv
 package model
 
-import "github.com/charmbracelet/huh"
+import (
+	"context"
+
+	"github.com/charmbracelet/huh"
+)
 
 type Vault interface {
 	// returns current instance as string for wizards
@@ -33,22 +37,22 @@ type Vault interface {
 }
 
 type List interface {
-	//elevate to list secrets (azure pim, aws ssm, etc)
-	ListElevate() error
+	//elevate to list secrets (azure pim, aws ssm, etc). ctx is cancelled on interrupt
+	ListElevate(ctx context.Context) error
 	// list all secrets
 	List() ([]Secret, error)
 }
 
 type Push interface {
-	// elevate permissions to push secrets (azure pim, aws ssm, etc)
-	PushElevate() error
+	// elevate permissions to push secrets (azure pim, aws ssm, etc). ctx is cancelled on interrupt
+	PushElevate(ctx context.Context) error
 	// push content to a secret
 	Push(s SecretContent) error
 }
 
 type Pull interface {
-	//elevate permissions to pull secrets (azure pim, aws ssm, etc)
-	PullElevate() error
+	//elevate permissions to pull secrets (azure pim, aws ssm, etc). ctx is cancelled on interrupt
+	PullElevate(ctx context.Context) error
 	// pull content from a secret
 	Pull(Secret) (SecretContent, error)
 }
@@ -18,33 +18,77 @@ var SelectedWriter model.Writer
 var SelectedFormatter model.Formatter
 
 var Sources = map[string]func() model.Source{
-	// "ots": func() model.Source { return &OneTimeSecretSource{} },
-	// "env": func() model.Source { return &EnvSource{} },
+	"stdin": func() model.Source { return &StdinSource{} },
+	"file":  func() model.Source { return &FileSource{} },
+	"env":   func() model.Source { return &EnvSource{} },
+	"ots":   func() model.Source { return &OneTimeSecretSource{} },
 }
 
 var InputFormatters = map[string]func() model.Formatter{
 	"json":    func() model.Formatter { return &JSONFormatter{} },
 	"jsonArr": func() model.Formatter { return &JSONFormatter{AsArray: true} },
 	"dotenv":  func() model.Formatter { return &DotenvFormatter{} },
+	"yaml":    func() model.Formatter { return &YAMLFormatter{} },
+	"toml":    func() model.Formatter { return &TOMLFormatter{} },
 }
 
+// order input formatters are detected in. dotenv accepts almost anything so it should be last.
+// input formatters not in this list are tried after, in alphabetical order
+var InputDetectOrder = []string{"json", "jsonArr", "yaml", "toml", "dotenv"}
+
 var OutputFormatters = map[string]func() model.Formatter{
-	"json":     func() model.Formatter { return &JSONFormatter{AsArray: false} },
-	"jsonArr":  func() model.Formatter { return &JSONFormatter{AsArray: true} },
-	"pwsh":     func() model.Formatter { return &PwshFormatter{} },
-	"stats":    func() model.Formatter { return &StatsFormatter{} },
-	"dotenv":   func() model.Formatter { return &DotenvFormatter{} },
-	"azdevops": func() model.Formatter { return &AzDevopsFormatter{} },
-	"pick":     func() model.Formatter { return &PickFormatter{} },
-	"posix":    func() model.Formatter { return &PosixFormatter{} },
-	"bash":     func() model.Formatter { return &PosixFormatter{} },
+	"json":           func() model.Formatter { return &JSONFormatter{AsArray: false} },
+	"jsonArr":        func() model.Formatter { return &JSONFormatter{AsArray: true} },
+	"pwsh":           func() model.Formatter { return &PwshFormatter{} },
+	"stats":          func() model.Formatter { return &InspectFormatter{} },
+	"inspect":        func() model.Formatter { return &InspectFormatter{} },
+	"inspect-json":   func() model.Formatter { return &InspectFormatter{Output: InspectJSON} },
+	"inspect-csv":    func() model.Formatter { return &InspectFormatter{Output: InspectCSV} },
+	"dotenv":         func() model.Formatter { return &DotenvFormatter{} },
+	"azdevops":       func() model.Formatter { return &AzDevopsFormatter{} },
+	"pick":           func() model.Formatter { return &PickFormatter{} },
+	"posix":          func() model.Formatter { return &PosixFormatter{} },
+	"bash":           func() model.Formatter { return &PosixFormatter{} },
+	"fish":           func() model.Formatter { return &FishFormatter{} },
+	"nu":             func() model.Formatter { return &NuFormatter{} },
+	"cmd":            func() model.Formatter { return &CmdFormatter{} },
+	"yaml":           func() model.Formatter { return &YAMLFormatter{} },
+	"yaml-flat":      func() model.Formatter { return &YAMLFormatter{Flat: true} },
+	"toml":           func() model.Formatter { return &TOMLFormatter{} },
+	"toml-flat":      func() model.Formatter { return &TOMLFormatter{Flat: true} },
+	"docker-env":     func() model.Formatter { return &DockerEnvFormatter{} },
+	"systemd":        func() model.Formatter { return &SystemdFormatter{} },
+	"compose":        func() model.Formatter { return &ComposeFormatter{} },
+	"tfvars":         func() model.Formatter { return &TerraformFormatter{} },
+	"tfvars-json":    func() model.Formatter { return &TerraformFormatter{JSON: true} },
+	"properties":     func() model.Formatter { return &PropertiesFormatter{} },
+	"appsettings":    func() model.Formatter { return &AppSettingsFormatter{} },
+	"launchsettings": func() model.Formatter { return &LaunchSettingsFormatter{} },
+	"vscode":         func() model.Formatter { return &VSCodeFormatter{} },
+	"template":       func() model.Formatter { return &TemplateFormatter{} },
+	"k8s-secret":     func() model.Formatter { return &K8sFormatter{Kind: K8sSecret} },
+	"k8s-configmap":  func() model.Formatter { return &K8sFormatter{Kind: K8sConfigMap} },
+	"github":         func() model.Formatter { return &GithubFormatter{} },
+	"gitlab":         func() model.Formatter { return &GitlabFormatter{} },
+	"jenkins":        func() model.Formatter { return &JenkinsFormatter{} },
 }
 
 var Writers = map[string]func() model.Writer{
 	"stdout":     func() model.Writer { return &StdOutWriter{} },
 	"github-env": func() model.Writer { return &GithubWriter{typ: GithubToEnv} },
 	"github-out": func() model.Writer { return &GithubWriter{typ: GithubToOutput} },
-	// "ots":        func() model.Writer { return &OtsWriter{} },
+	"ots":        func() model.Writer { return &OtsWriter{} },
+	"file":       func() model.Writer { return &FileWriter{} },
+	// ci systems that pick up a file
+	"gitlab-dotenv": func() model.Writer {
+		return &CIFileWriter{name: "gitlab-dotenv", format: "gitlab", defaultPath: "polyenv.env"}
+	},
+	"bitbucket": func() model.Writer {
+		return &CIFileWriter{name: "bitbucket", format: "posix", defaultPath: "set_env.sh"}
+	},
+	"jenkins": func() model.Writer {
+		return &CIFileWriter{name: "jenkins", format: "jenkins", defaultPath: "polyenv.groovy"}
+	},
 }
 
 func init() {
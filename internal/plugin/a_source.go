@@ -0,0 +1,172 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/withholm/polyenv/internal/model"
+	"github.com/withholm/polyenv/internal/tools"
+)
+
+// finds the source for the given argument.
+// the argument is either a source name (ie 'stdin') or something a source detects (ie a file path)
+func FindSource(arg string) (model.Source, error) {
+	name, _, _ := strings.Cut(arg, ":")
+	if f, ok := tools.InequalFindInMap(Sources, name); ok {
+		src := f()
+		if src.Detect(arg) {
+			return src, nil
+		}
+	}
+
+	names := tools.MapKeySlice(Sources)
+	slices.Sort(names)
+	for _, n := range names {
+		src := Sources[n]()
+		if src.Detect(arg) {
+			slog.Debug("detected source", "source", n, "arg", arg)
+			return src, nil
+		}
+	}
+	return nil, fmt.Errorf("no source can handle '%s'. available sources: %v", arg, names)
+}
+
+// detects format of data using the input formatters and returns the values
+func ParseInput(data []byte) ([]model.StoredEnv, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return []model.StoredEnv{}, nil
+	}
+
+	for _, name := range inputFormatterOrder() {
+		f := InputFormatters[name]()
+		if !f.Detect(data) {
+			continue
+		}
+		slog.Debug("detected input format", "format", name)
+		in, err := f.InputFormat(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read input as %s: %w", name, err)
+		}
+		return InputToEnv(in)
+	}
+	return nil, fmt.Errorf("could not detect format of input")
+}
+
+// returns input formatter names in the order they should be detected
+func inputFormatterOrder() []string {
+	out := make([]string, 0, len(InputFormatters))
+	for _, name := range InputDetectOrder {
+		if _, ok := InputFormatters[name]; ok {
+			out = append(out, name)
+		}
+	}
+	rest := tools.MapKeySlice(InputFormatters)
+	slices.Sort(rest)
+	for _, name := range rest {
+		if !slices.Contains(out, name) {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// converts input data from a formatter to env values, sorted by key
+func InputToEnv(in *model.InputData) ([]model.StoredEnv, error) {
+	if in == nil {
+		return nil, fmt.Errorf("no input data")
+	}
+
+	out := make([]model.StoredEnv, 0)
+	switch {
+	case in.IsMap:
+		switch m := in.Value.(type) {
+		case map[string]string:
+			for k, v := range m {
+				out = append(out, model.StoredEnv{Key: k, Value: v})
+			}
+		case map[string]any:
+			for k, v := range m {
+				str, err := inputValueString(v)
+				if err != nil {
+					return nil, fmt.Errorf("failed to read value of %s: %w", k, err)
+				}
+				out = append(out, model.StoredEnv{Key: k, Value: str})
+			}
+		default:
+			return nil, fmt.Errorf("unsupported map type %T", in.Value)
+		}
+	case in.IsSlice:
+		var items []any
+		switch s := in.Value.(type) {
+		case []any:
+			items = s
+		case []string:
+			for _, v := range s {
+				items = append(items, v)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported slice type %T", in.Value)
+		}
+		for i, item := range items {
+			env, err := inputItemToEnv(item)
+			if err != nil {
+				return nil, fmt.Errorf("item %d: %w", i, err)
+			}
+			out = append(out, env)
+		}
+	default:
+		return nil, fmt.Errorf("cannot convert a single value to env values")
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Key < out[j].Key
+	})
+	return out, nil
+}
+
+// converts a slice item. supports "KEY=value" and {"key":"KEY","value":"value"}
+func inputItemToEnv(item any) (model.StoredEnv, error) {
+	switch v := item.(type) {
+	case string:
+		k, val, ok := strings.Cut(v, "=")
+		if !ok || k == "" {
+			return model.StoredEnv{}, fmt.Errorf("expected KEY=value, got '%s'", v)
+		}
+		return model.StoredEnv{Key: k, Value: val}, nil
+	case map[string]any:
+		k, ok := v["key"].(string)
+		if !ok || k == "" {
+			return model.StoredEnv{}, fmt.Errorf("missing 'key'")
+		}
+		val, err := inputValueString(v["value"])
+		if err != nil {
+			return model.StoredEnv{}, err
+		}
+		return model.StoredEnv{Key: k, Value: val}, nil
+	}
+	return model.StoredEnv{}, fmt.Errorf("unsupported item type %T", item)
+}
+
+// converts a decoded value to string. non-string values are json encoded
+func inputValueString(v any) (string, error) {
+	switch val := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return val, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
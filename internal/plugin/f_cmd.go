@@ -0,0 +1,73 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package plugin
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/withholm/polyenv/internal/model"
+)
+
+// `set "KEY=value"` for cmd.exe batch files.
+// special characters are literal inside the quotes, except % which is doubled for batch files.
+// cmd cannot represent newlines in a value, so these fail the export
+type CmdFormatter struct {
+	//emit `set "KEY="` to unload the values
+	Unset bool
+}
+
+func (f *CmdFormatter) Name() string {
+	return "cmd"
+}
+
+func (f *CmdFormatter) Detect(data []byte) bool {
+	return false
+}
+
+func (f *CmdFormatter) InputFormat(data []byte) (*model.InputData, error) {
+	return nil, nil
+}
+
+func (f *CmdFormatter) OutputFormat(data []model.StoredEnv) ([]byte, error) {
+	var sb strings.Builder
+	sb.WriteString("@echo off\r\n")
+	for _, v := range data {
+		if v.Key == "" || strings.ContainsAny(v.Key, "=\"%\r\n") {
+			return nil, fmt.Errorf("key '%s' is not supported by cmd. it cannot contain '=', '\"', '%%' or newlines", v.Key)
+		}
+		if f.Unset {
+			sb.WriteString(`set "` + v.Key + "=\"\r\n")
+			continue
+		}
+		if strings.ContainsAny(v.Value, "\r\n") {
+			return nil, fmt.Errorf("value of %s cannot be represented in cmd. it contains newlines", v.Key)
+		}
+		if strings.Contains(v.Value, "!") {
+			slog.Warn("value contains '!', which is removed if delayed expansion is enabled", "key", v.Key)
+		}
+		sb.WriteString(`set "` + v.Key + "=" + cmdEscape(v.Value) + "\"\r\n")
+	}
+	return []byte(sb.String()), nil
+}
+
+// set uses everything up to the last quote, so quotes in the value are kept.
+// every quote toggles cmd's quoting though, so special characters outside of quotes are escaped with ^
+func cmdEscape(value string) string {
+	var sb strings.Builder
+	quoted := true
+	for _, r := range value {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == '%':
+			sb.WriteRune('%')
+		case !quoted && strings.ContainsRune("&|<>^()", r):
+			sb.WriteRune('^')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
@@ -0,0 +1,90 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package plugin
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/withholm/polyenv/internal/model"
+	"gopkg.in/yaml.v3"
+)
+
+// env file for `docker run --env-file`. docker reads everything after '=' literally, so there is no quoting
+type DockerEnvFormatter struct {
+}
+
+func (f *DockerEnvFormatter) Name() string {
+	return "docker-env"
+}
+
+func (f *DockerEnvFormatter) Detect(data []byte) bool {
+	return false
+}
+
+func (f *DockerEnvFormatter) InputFormat(data []byte) (*model.InputData, error) {
+	return nil, nil
+}
+
+func (f *DockerEnvFormatter) OutputFormat(data []model.StoredEnv) ([]byte, error) {
+	var sb strings.Builder
+	for _, v := range data {
+		if v.Key == "" || strings.HasPrefix(v.Key, "#") || strings.ContainsFunc(v.Key, func(r rune) bool { return r == '=' || unicode.IsSpace(r) }) {
+			return nil, fmt.Errorf("key '%s' is not supported by docker env files. it cannot contain '=' or whitespace, or start with '#'", v.Key)
+		}
+		if strings.ContainsAny(v.Value, "\r\n") {
+			return nil, fmt.Errorf("value of %s is multiline, which docker env files cannot represent", v.Key)
+		}
+		sb.WriteString(v.Key + "=" + v.Value + "\n")
+	}
+	return []byte(sb.String()), nil
+}
+
+// `environment:` block for docker compose. '$' is escaped as '$$' so compose does not interpolate values.
+// with Service set, it is a full override file (`docker compose -f compose.yaml -f polyenv.yaml up`)
+type ComposeFormatter struct {
+	Service string
+}
+
+func (f *ComposeFormatter) Name() string {
+	return "compose"
+}
+
+func (f *ComposeFormatter) Detect(data []byte) bool {
+	return false
+}
+
+func (f *ComposeFormatter) InputFormat(data []byte) (*model.InputData, error) {
+	return nil, nil
+}
+
+func (f *ComposeFormatter) OutputFormat(data []model.StoredEnv) ([]byte, error) {
+	env := make(map[string]string, len(data))
+	for _, v := range data {
+		if v.Key == "" || strings.ContainsAny(v.Key, "=$") {
+			return nil, fmt.Errorf("key '%s' is not supported by compose. it cannot contain '=' or '$'", v.Key)
+		}
+		env[v.Key] = strings.ReplaceAll(v.Value, "$", "$$")
+	}
+
+	var doc any = map[string]any{"environment": env}
+	if f.Service != "" {
+		doc = map[string]any{"services": map[string]any{f.Service: doc}}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	err := enc.Encode(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode yaml: %w", err)
+	}
+	err = enc.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,111 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/withholm/polyenv/internal/model"
+)
+
+// .net appsettings.json. keys are nested on '__' like .net does for env variables (Logging__LogLevel -> Logging.LogLevel).
+// case is kept, as .net configuration keys are case insensitive
+type AppSettingsFormatter struct {
+}
+
+func (f *AppSettingsFormatter) Name() string {
+	return "appsettings"
+}
+
+func (f *AppSettingsFormatter) Detect(data []byte) bool {
+	return false
+}
+
+func (f *AppSettingsFormatter) InputFormat(data []byte) (*model.InputData, error) {
+	return nil, nil
+}
+
+func (f *AppSettingsFormatter) OutputFormat(data []model.StoredEnv) ([]byte, error) {
+	doc, err := nestEnvCase(data, DefaultNestSeparator, false)
+	if err != nil {
+		return nil, err
+	}
+	return marshalIndentJSON(doc)
+}
+
+// .net launchSettings.json with a single profile setting the values as environment variables
+type LaunchSettingsFormatter struct {
+	//name of the profile. defaults to 'polyenv'
+	Profile string
+}
+
+func (f *LaunchSettingsFormatter) Name() string {
+	return "launchsettings"
+}
+
+func (f *LaunchSettingsFormatter) Detect(data []byte) bool {
+	return false
+}
+
+func (f *LaunchSettingsFormatter) InputFormat(data []byte) (*model.InputData, error) {
+	return nil, nil
+}
+
+func (f *LaunchSettingsFormatter) OutputFormat(data []model.StoredEnv) ([]byte, error) {
+	env, err := flatEnv(data)
+	if err != nil {
+		return nil, err
+	}
+	profile := f.Profile
+	if profile == "" {
+		profile = "polyenv"
+	}
+	doc := map[string]any{
+		"profiles": map[string]any{
+			profile: map[string]any{
+				"commandName":          "Project",
+				"environmentVariables": env,
+			},
+		},
+	}
+	return marshalIndentJSON(doc)
+}
+
+// 'env' block of a vs code launch.json configuration
+type VSCodeFormatter struct {
+}
+
+func (f *VSCodeFormatter) Name() string {
+	return "vscode"
+}
+
+func (f *VSCodeFormatter) Detect(data []byte) bool {
+	return false
+}
+
+func (f *VSCodeFormatter) InputFormat(data []byte) (*model.InputData, error) {
+	return nil, nil
+}
+
+func (f *VSCodeFormatter) OutputFormat(data []model.StoredEnv) ([]byte, error) {
+	env, err := flatEnv(data)
+	if err != nil {
+		return nil, err
+	}
+	return marshalIndentJSON(map[string]any{"env": env})
+}
+
+// json with 2 space indent, without escaping html characters
+func marshalIndentJSON(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(v)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,51 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package plugin
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/withholm/polyenv/internal/model"
+)
+
+// valid variable names in fish and posix shells
+var shellKeyRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// fish only knows \\ and \' inside single quotes
+var fishEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+// `set -gx KEY 'value'` for the fish shell. load with `polyenv export --as fish | source`
+type FishFormatter struct {
+	//emit `set -e KEY` to unload the values
+	Unset bool
+}
+
+func (f *FishFormatter) Name() string {
+	return "fish"
+}
+
+func (f *FishFormatter) Detect(data []byte) bool {
+	return false
+}
+
+func (f *FishFormatter) InputFormat(data []byte) (*model.InputData, error) {
+	return nil, nil
+}
+
+func (f *FishFormatter) OutputFormat(data []model.StoredEnv) ([]byte, error) {
+	var sb strings.Builder
+	for _, v := range data {
+		if !shellKeyRegex.MatchString(v.Key) {
+			return nil, fmt.Errorf("key '%s' is not a valid fish variable name", v.Key)
+		}
+		if f.Unset {
+			sb.WriteString("set -e " + v.Key + "\n")
+			continue
+		}
+		sb.WriteString("set -gx " + v.Key + " '" + fishEscaper.Replace(v.Value) + "'\n")
+	}
+	return []byte(sb.String()), nil
+}
@@ -0,0 +1,66 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package plugin
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/withholm/polyenv/internal/model"
+)
+
+// github actions environment file ($GITHUB_ENV/$GITHUB_OUTPUT).
+// values are written as is (github does not unquote), multiline values use 'KEY<<DELIM' heredocs
+// https://docs.github.com/en/actions/reference/workflows-and-actions/workflow-commands#multiline-strings
+type GithubFormatter struct {
+}
+
+// generates a random heredoc delimiter. var so tests can make it predictable
+var githubDelimiter = func() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return "ghadelimiter_" + hex.EncodeToString(b), nil
+}
+
+func (f *GithubFormatter) Name() string {
+	return "github"
+}
+
+func (f *GithubFormatter) Detect(data []byte) bool {
+	return false
+}
+
+func (f *GithubFormatter) InputFormat(data []byte) (*model.InputData, error) {
+	return nil, nil
+}
+
+func (f *GithubFormatter) OutputFormat(data []model.StoredEnv) ([]byte, error) {
+	var sb strings.Builder
+	for _, v := range data {
+		if v.Key == "" || strings.ContainsAny(v.Key, "= \t\r\n") || strings.Contains(v.Key, "<<") {
+			return nil, fmt.Errorf("key '%s' is not supported by github. it cannot contain '=', '<<' or whitespace", v.Key)
+		}
+
+		if !strings.ContainsAny(v.Value, "\r\n") {
+			sb.WriteString(v.Key + "=" + v.Value + "\n")
+			continue
+		}
+
+		delim, err := githubDelimiter()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate delimiter: %w", err)
+		}
+		// should not happen with a random delimiter, but a value containing it would end the heredoc early
+		if strings.Contains(v.Value, delim) {
+			return nil, fmt.Errorf("value of %s contains the heredoc delimiter", v.Key)
+		}
+		sb.WriteString(v.Key + "<<" + delim + "\n" + v.Value + "\n" + delim + "\n")
+	}
+	return []byte(sb.String()), nil
+}
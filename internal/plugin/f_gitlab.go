@@ -0,0 +1,62 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package plugin
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/withholm/polyenv/internal/model"
+)
+
+var (
+	// gitlab only accepts these variable names in dotenv reports
+	gitlabKeyRegex = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+	// values gitlab is able to mask. https://docs.gitlab.com/ci/variables/#mask-a-cicd-variable
+	gitlabMaskableRegex = regexp.MustCompile(`^[a-zA-Z0-9+/=@:.~_\-]{8,}$`)
+)
+
+// gitlab dotenv report (artifacts:reports:dotenv).
+// values are written as is, as gitlab does not support quotes or multiline values
+type GitlabFormatter struct {
+}
+
+func (f *GitlabFormatter) Name() string {
+	return "gitlab"
+}
+
+func (f *GitlabFormatter) Detect(data []byte) bool {
+	return false
+}
+
+func (f *GitlabFormatter) InputFormat(data []byte) (*model.InputData, error) {
+	return nil, nil
+}
+
+func (f *GitlabFormatter) OutputFormat(data []model.StoredEnv) ([]byte, error) {
+	var sb strings.Builder
+	for _, v := range data {
+		if !gitlabKeyRegex.MatchString(v.Key) {
+			return nil, fmt.Errorf("key '%s' is not supported by gitlab. only letters, digits and '_' are allowed", v.Key)
+		}
+		if strings.ContainsAny(v.Value, "\r\n") {
+			return nil, fmt.Errorf("value of %s is multiline, which is not supported by gitlab dotenv reports", v.Key)
+		}
+
+		secret := v.IsSecret
+		if !secret {
+			secret, _ = v.DetectSecret()
+		}
+		if secret {
+			// dotenv reports are stored as artifacts. inherited variables are only masked if the value can be masked
+			if !gitlabMaskableRegex.MatchString(v.Value) {
+				slog.Warn("secret cannot be masked by gitlab and may show up in job logs. it needs to be at least 8 characters of a-z, 0-9, and +/=@:.~_-", "key", v.Key)
+			}
+		}
+		sb.WriteString(v.Key + "=" + v.Value + "\n")
+	}
+	return []byte(sb.String()), nil
+}
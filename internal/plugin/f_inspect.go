@@ -0,0 +1,292 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package plugin
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/withholm/polyenv/internal/model"
+	"github.com/withholm/polyenv/internal/polyenvfile"
+	"github.com/withholm/polyenv/internal/tools"
+)
+
+type InspectOutput string
+
+const (
+	InspectTable InspectOutput = "table"
+	InspectJSON  InspectOutput = "json"
+	InspectCSV   InspectOutput = "csv"
+)
+
+// tags set on report entries
+const (
+	InspectTagSecret   = "sec"
+	InspectTagDup      = "dup"
+	InspectTagEmpty    = "empty"
+	InspectTagShadowed = "shadowed"
+	InspectTagCase     = "case"
+	InspectTagMissing  = "missing"
+)
+
+// report of the values in an environment: secrets, duplicates, empty values and missing secrets
+type InspectFormatter struct {
+	PolyenvFile *polyenvfile.File
+	Output      InspectOutput
+}
+
+type InspectEntry struct {
+	Key  string   `json:"key"`
+	Tags []string `json:"tags"`
+	//path relative to git root. empty for missing secrets
+	File         string `json:"file"`
+	Vault        string `json:"vault,omitempty"`
+	SecretReason string `json:"secret_reason,omitempty"`
+	//file of the value that is used instead of this one
+	ShadowedBy string `json:"shadowed_by,omitempty"`
+}
+
+type InspectSummary struct {
+	Keys           int `json:"keys"`
+	Values         int `json:"values"`
+	Secrets        int `json:"secrets"`
+	Empty          int `json:"empty"`
+	Shadowed       int `json:"shadowed"`
+	MissingSecrets int `json:"missing_secrets"`
+	CaseCollisions int `json:"case_collisions"`
+}
+
+type InspectReport struct {
+	Entries []InspectEntry `json:"entries"`
+	Summary InspectSummary `json:"summary"`
+}
+
+func (f *InspectFormatter) Name() string {
+	switch f.Output {
+	case InspectJSON:
+		return "inspect-json"
+	case InspectCSV:
+		return "inspect-csv"
+	}
+	return "inspect"
+}
+
+func (f *InspectFormatter) Detect(data []byte) bool {
+	return false
+}
+
+func (f *InspectFormatter) InputFormat(data []byte) (*model.InputData, error) {
+	return nil, nil
+}
+
+func (f *InspectFormatter) OutputFormat(data []model.StoredEnv) ([]byte, error) {
+	root, err := tools.GetGitRootOrCwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get git root: %w", err)
+	}
+	var secrets map[string]model.Secret
+	if f.PolyenvFile != nil {
+		secrets = f.PolyenvFile.Secrets
+	}
+	report := Inspect(data, secrets, root)
+
+	switch f.Output {
+	case InspectJSON:
+		return marshalIndentJSON(report)
+	case InspectCSV:
+		return report.csv()
+	}
+	return report.table(), nil
+}
+
+// analyses the values. the last value of a key is the one that is used, like when exporting.
+// secrets are the secrets of the polyenv file, by local key
+func Inspect(data []model.StoredEnv, secrets map[string]model.Secret, root string) InspectReport {
+	// last occurrence of each key wins
+	winner := make(map[string]int)
+	// distinct keys by uppercase
+	byCase := make(map[string][]string)
+	for i, v := range data {
+		winner[v.Key] = i
+		upper := strings.ToUpper(v.Key)
+		if !slices.Contains(byCase[upper], v.Key) {
+			byCase[upper] = append(byCase[upper], v.Key)
+		}
+	}
+
+	// vault secrets by every local key they write to
+	vaultKeys := make(map[string]model.Secret)
+	for k, s := range secrets {
+		vaultKeys[k] = s
+		if s.LocalKey != "" {
+			vaultKeys[s.LocalKey] = s
+		}
+		if s.KeyLocalKey != "" {
+			vaultKeys[s.KeyLocalKey] = s
+		}
+	}
+
+	report := InspectReport{Entries: []InspectEntry{}}
+	counted := make(map[string]bool)
+	for i, v := range data {
+		entry := InspectEntry{Key: v.Key, Tags: []string{}, File: relativePath(root, v.File)}
+
+		sec, fromVault := vaultKeys[v.Key]
+		if ref, ok, _ := model.ParseVaultReference(v.Key, v.Value); ok {
+			sec, fromVault = ref, true
+		}
+		isSecret := v.IsSecret || fromVault
+		if fromVault {
+			entry.Vault = sec.Vault
+		} else if !isSecret {
+			isSecret, entry.SecretReason = v.DetectSecret()
+		}
+		if isSecret {
+			entry.Tags = append(entry.Tags, InspectTagSecret)
+			report.Summary.Secrets++
+		}
+
+		if winner[v.Key] != i {
+			entry.Tags = append(entry.Tags, InspectTagDup, InspectTagShadowed)
+			entry.ShadowedBy = relativePath(root, data[winner[v.Key]].File)
+			report.Summary.Shadowed++
+		} else if slices.ContainsFunc(data, func(o model.StoredEnv) bool { return o.Key == v.Key && o.File != v.File }) {
+			entry.Tags = append(entry.Tags, InspectTagDup)
+		}
+
+		if v.Value == "" {
+			entry.Tags = append(entry.Tags, InspectTagEmpty)
+			report.Summary.Empty++
+		}
+
+		if len(byCase[strings.ToUpper(v.Key)]) > 1 {
+			entry.Tags = append(entry.Tags, InspectTagCase)
+			if !counted[strings.ToUpper(v.Key)] {
+				counted[strings.ToUpper(v.Key)] = true
+				report.Summary.CaseCollisions++
+			}
+		}
+		report.Entries = append(report.Entries, entry)
+	}
+
+	// secrets in the polyenv file that are not pulled
+	names := tools.MapKeySlice(secrets)
+	slices.Sort(names)
+	for _, name := range names {
+		s := secrets[name]
+		local := s.LocalKey
+		if local == "" {
+			local = name
+		}
+		for _, k := range []string{local, s.KeyLocalKey} {
+			if k == "" {
+				continue
+			}
+			if _, ok := winner[k]; ok {
+				continue
+			}
+			report.Entries = append(report.Entries, InspectEntry{
+				Key:   k,
+				Tags:  []string{InspectTagSecret, InspectTagMissing},
+				Vault: s.Vault,
+			})
+			report.Summary.MissingSecrets++
+		}
+	}
+
+	slices.SortStableFunc(report.Entries, func(a, b InspectEntry) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	report.Summary.Keys = len(winner)
+	report.Summary.Values = len(data)
+	return report
+}
+
+func relativePath(root, path string) string {
+	if path == "" {
+		return ""
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return rel
+}
+
+func (r InspectReport) footer() string {
+	s := r.Summary
+	return fmt.Sprintf("%d keys (%d values), %d secrets, %d empty, %d shadowed, %d missing secrets, %d case collisions",
+		s.Keys, s.Values, s.Secrets, s.Empty, s.Shadowed, s.MissingSecrets, s.CaseCollisions)
+}
+
+func (r InspectReport) csv() ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	rows := [][]string{{"key", "tags", "file", "vault", "secret_reason", "shadowed_by"}}
+	for _, e := range r.Entries {
+		rows = append(rows, []string{e.Key, strings.Join(e.Tags, ","), e.File, e.Vault, e.SecretReason, e.ShadowedBy})
+	}
+	err := w.WriteAll(rows)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (r InspectReport) table() []byte {
+	rows := make([]table.Row, len(r.Entries))
+	longestPath := len("Path")
+	longestName := len("Name")
+	longestTags := len("Tags")
+	longestVault := len("SecretVault")
+	longestReason := len("SecretReason")
+	longestShadow := len("ShadowedBy")
+	for i, e := range r.Entries {
+		tags := strings.Join(e.Tags, ",")
+		rows[i] = table.Row{e.Key, tags, e.File, e.Vault, e.SecretReason, e.ShadowedBy}
+		longestName = max(longestName, len(e.Key))
+		longestTags = max(longestTags, len(tags))
+		longestPath = max(longestPath, len(e.File))
+		longestVault = max(longestVault, len(e.Vault))
+		longestReason = max(longestReason, len(e.SecretReason))
+		longestShadow = max(longestShadow, len(e.ShadowedBy))
+		slog.Debug("row", "row", rows[i])
+	}
+
+	columns := []table.Column{
+		{Title: "Name", Width: longestName},
+		{Title: "Tags", Width: longestTags},
+		{Title: "Path", Width: longestPath},
+		{Title: "SecretVault", Width: longestVault},
+		{Title: "SecretReason", Width: longestReason},
+		{Title: "ShadowedBy", Width: longestShadow},
+	}
+
+	t := table.New(
+		table.WithColumns(columns),
+		table.WithRows(rows),
+		table.WithHeight(len(rows)+1),
+		table.WithFocused(false),
+	)
+
+	t.Blur()
+	s := table.DefaultStyles()
+	s.Header = s.Header.
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color("240")).
+		BorderBottom(true).
+		Bold(true)
+	s.Selected = s.Cell
+	t.SetStyles(s)
+
+	footer := lipgloss.NewStyle().Faint(true).Render(r.footer())
+	return []byte(t.View() + "\n" + footer + "\n")
+}
@@ -0,0 +1,46 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package plugin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/withholm/polyenv/internal/model"
+)
+
+// groovy script setting env on a jenkins pipeline. use with `load 'polyenv.groovy'`
+type JenkinsFormatter struct {
+}
+
+var groovyEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`'`, `\'`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\t", `\t`,
+)
+
+func (f *JenkinsFormatter) Name() string {
+	return "jenkins"
+}
+
+func (f *JenkinsFormatter) Detect(data []byte) bool {
+	return false
+}
+
+func (f *JenkinsFormatter) InputFormat(data []byte) (*model.InputData, error) {
+	return nil, nil
+}
+
+func (f *JenkinsFormatter) OutputFormat(data []model.StoredEnv) ([]byte, error) {
+	var sb strings.Builder
+	for _, v := range data {
+		if !shellKeyRegex.MatchString(v.Key) {
+			return nil, fmt.Errorf("key '%s' is not a valid jenkins environment variable name", v.Key)
+		}
+		sb.WriteString("env['" + v.Key + "'] = '" + groovyEscaper.Replace(v.Value) + "'\n")
+	}
+	return []byte(sb.String()), nil
+}
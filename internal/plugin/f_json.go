@@ -59,7 +59,8 @@ func (f *JSONFormatter) InputFormat(data []byte) (*model.InputData, error) {
 		return &model.InputData{IsMap: true, Value: out}, nil
 	}
 
-	var out []string
+	// either ["KEY=value"] or [{"key":"KEY","value":"value"}]
+	var out []any
 	err := json.Unmarshal(data, &out)
 	if err != nil {
 		return nil, err
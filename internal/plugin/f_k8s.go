@@ -0,0 +1,184 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package plugin
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/withholm/polyenv/internal/model"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	K8sSecret    = "Secret"
+	K8sConfigMap = "ConfigMap"
+
+	// default name of the manifests
+	k8sDefaultName = "polyenv"
+)
+
+var (
+	// keys of secrets and configmaps
+	k8sKeyRegex = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+	// rfc 1123 subdomain, used for resource names and namespaces
+	k8sNameRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+)
+
+// kubernetes manifests. secrets (IsSecret or detected) go to a Secret, the rest to a ConfigMap
+type K8sFormatter struct {
+	//Secret or ConfigMap
+	Kind string
+	//name of the resources. defaults to 'polyenv'
+	ResourceName string
+	Namespace    string
+	Labels       map[string]string
+	//output both Secret and ConfigMap as a multi document stream
+	Stream bool
+}
+
+type k8sManifest struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   k8sMetadata       `yaml:"metadata"`
+	Type       string            `yaml:"type,omitempty"`
+	Data       map[string]string `yaml:"data"`
+}
+
+type k8sMetadata struct {
+	Name      string            `yaml:"name"`
+	Namespace string            `yaml:"namespace,omitempty"`
+	Labels    map[string]string `yaml:"labels,omitempty"`
+}
+
+func (f *K8sFormatter) Name() string {
+	if f.Kind == K8sConfigMap {
+		return "k8s-configmap"
+	}
+	return "k8s-secret"
+}
+
+func (f *K8sFormatter) Detect(data []byte) bool {
+	return false
+}
+
+func (f *K8sFormatter) InputFormat(data []byte) (*model.InputData, error) {
+	return nil, fmt.Errorf("k8s formatter does not support input")
+}
+
+func (f *K8sFormatter) OutputFormat(data []model.StoredEnv) ([]byte, error) {
+	if f.Kind != K8sSecret && f.Kind != K8sConfigMap {
+		return nil, fmt.Errorf("unknown kind '%s'. expected %s or %s", f.Kind, K8sSecret, K8sConfigMap)
+	}
+	meta, err := f.metadata()
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := make(map[string]string)
+	config := make(map[string]string)
+	for _, env := range data {
+		if !k8sKeyRegex.MatchString(env.Key) {
+			return nil, fmt.Errorf("key '%s' is not a valid kubernetes key. only alphanumerics, '-', '_' and '.' are allowed", env.Key)
+		}
+		isSecret := env.IsSecret
+		if !isSecret {
+			isSecret, _ = env.DetectSecret()
+		}
+		if isSecret {
+			secrets[env.Key] = base64.StdEncoding.EncodeToString([]byte(env.Value))
+		} else {
+			config[env.Key] = env.Value
+		}
+	}
+
+	secret := k8sManifest{APIVersion: "v1", Kind: K8sSecret, Metadata: meta, Type: "Opaque", Data: secrets}
+	configMap := k8sManifest{APIVersion: "v1", Kind: K8sConfigMap, Metadata: meta, Data: config}
+
+	docs := make([]k8sManifest, 0, 2)
+	switch {
+	case f.Stream:
+		// skip empty documents, but always output something
+		if len(config) > 0 || len(secrets) == 0 {
+			docs = append(docs, configMap)
+		}
+		if len(secrets) > 0 {
+			docs = append(docs, secret)
+		}
+	case f.Kind == K8sSecret:
+		if len(config) > 0 {
+			slog.Warn("values that are not secrets are not included in the Secret. use stream to also output a ConfigMap", "skipped", len(config))
+		}
+		docs = append(docs, secret)
+	default:
+		if len(secrets) > 0 {
+			slog.Warn("secrets are not included in the ConfigMap. use stream to also output a Secret", "skipped", len(secrets))
+		}
+		docs = append(docs, configMap)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	for _, doc := range docs {
+		err = enc.Encode(doc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", doc.Kind, err)
+		}
+	}
+	err = enc.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// converts any name, ie an environment name, to a valid kubernetes name.
+// it is lowercased, other characters than alphanumerics, '-' and '.' become '-'
+func K8sName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '.':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('-')
+		}
+	}
+	// every part between dots has to start and end with an alphanumeric
+	var parts []string
+	for _, part := range strings.Split(b.String(), ".") {
+		part = strings.Trim(part, "-")
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	out := strings.Join(parts, ".")
+	if len(out) > 253 {
+		out = strings.TrimRight(out[:253], "-.")
+	}
+	if out == "" {
+		return k8sDefaultName
+	}
+	return out
+}
+
+// validates and returns metadata of the manifests
+func (f *K8sFormatter) metadata() (k8sMetadata, error) {
+	name := f.ResourceName
+	if name == "" {
+		name = k8sDefaultName
+	}
+	if len(name) > 253 || !k8sNameRegex.MatchString(name) {
+		return k8sMetadata{}, fmt.Errorf("name '%s' is not a valid kubernetes name. use lowercase alphanumerics, '-' and '.'", name)
+	}
+	if f.Namespace != "" && (len(f.Namespace) > 63 || !k8sNameRegex.MatchString(f.Namespace)) {
+		return k8sMetadata{}, fmt.Errorf("namespace '%s' is not a valid kubernetes namespace", f.Namespace)
+	}
+	return k8sMetadata{Name: name, Namespace: f.Namespace, Labels: f.Labels}, nil
+}
@@ -0,0 +1,53 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package plugin
+
+import (
+	"strings"
+
+	"github.com/withholm/polyenv/internal/model"
+)
+
+// nushell double quoted strings support c-style escapes
+var nuEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\t", `\t`,
+)
+
+// `$env.KEY = "value"` for nushell. save to a file and `source` it
+type NuFormatter struct {
+	//emit `hide-env` to unload the values
+	Unset bool
+}
+
+func (f *NuFormatter) Name() string {
+	return "nu"
+}
+
+func (f *NuFormatter) Detect(data []byte) bool {
+	return false
+}
+
+func (f *NuFormatter) InputFormat(data []byte) (*model.InputData, error) {
+	return nil, nil
+}
+
+func (f *NuFormatter) OutputFormat(data []model.StoredEnv) ([]byte, error) {
+	var sb strings.Builder
+	for _, v := range data {
+		key := v.Key
+		if !shellKeyRegex.MatchString(key) {
+			key = `"` + nuEscaper.Replace(key) + `"`
+		}
+		if f.Unset {
+			sb.WriteString("hide-env -i " + key + "\n")
+			continue
+		}
+		sb.WriteString("$env." + key + ` = "` + nuEscaper.Replace(v.Value) + "\"\n")
+	}
+	return []byte(sb.String()), nil
+}
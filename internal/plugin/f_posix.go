@@ -4,13 +4,19 @@
 package plugin
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/joho/godotenv"
 	"github.com/withholm/polyenv/internal/model"
 )
 
-type PosixFormatter struct{}
+type PosixFormatter struct {
+	//emit `unset KEY` to unload the values
+	Unset bool
+	//export as terraform input variables (TF_VAR_name)
+	TfVar bool
+}
 
 func (f *PosixFormatter) Name() string {
 	return "posix"
@@ -28,6 +34,25 @@ func (f *PosixFormatter) InputFormat(data []byte) (*model.InputData, error) {
 
 // OutputFormat converts secrets to the `export KEY="VALUE"` format.
 func (f *PosixFormatter) OutputFormat(data []model.StoredEnv) ([]byte, error) {
+	if f.TfVar {
+		var err error
+		data, err = terraformEnv(data, "TF_VAR_")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if f.Unset {
+		var out []string
+		for _, v := range data {
+			if !shellKeyRegex.MatchString(v.Key) {
+				return nil, fmt.Errorf("key '%s' is not a valid shell variable name", v.Key)
+			}
+			out = append(out, "unset "+v.Key)
+		}
+		return []byte(strings.Join(out, "\n")), nil
+	}
+
 	// Convert the StoredEnv slice to a map for godotenv
 	envMap := make(map[string]string, len(data))
 	for _, v := range data {
@@ -0,0 +1,87 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package plugin
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/withholm/polyenv/internal/model"
+)
+
+// java .properties file. keys are lowercased and nested with '.' (DB__HOST -> db.host).
+// escapes the same way as java's Properties.store, so non-ascii characters are written as \uXXXX
+type PropertiesFormatter struct {
+	//separator used to nest keys. defaults to '__'
+	Separator string
+}
+
+func (f *PropertiesFormatter) Name() string {
+	return "properties"
+}
+
+func (f *PropertiesFormatter) Detect(data []byte) bool {
+	return false
+}
+
+func (f *PropertiesFormatter) InputFormat(data []byte) (*model.InputData, error) {
+	return nil, nil
+}
+
+func (f *PropertiesFormatter) OutputFormat(data []model.StoredEnv) ([]byte, error) {
+	separator := f.Separator
+	if separator == "" {
+		separator = DefaultNestSeparator
+	}
+
+	seen := make(map[string]string, len(data))
+	var sb strings.Builder
+	for _, v := range data {
+		key := strings.ReplaceAll(strings.ToLower(v.Key), strings.ToLower(separator), ".")
+		if existing, ok := seen[key]; ok {
+			return nil, fmt.Errorf("both '%s' and '%s' becomes property '%s'", existing, v.Key, key)
+		}
+		seen[key] = v.Key
+		sb.WriteString(propertiesEscape(key, true) + "=" + propertiesEscape(v.Value, false) + "\n")
+	}
+	return []byte(sb.String()), nil
+}
+
+// escapes a key or value for a .properties file
+func propertiesEscape(s string, key bool) string {
+	var sb strings.Builder
+	for i, r := range s {
+		switch r {
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\f':
+			sb.WriteString(`\f`)
+		case '=', ':', '#', '!':
+			sb.WriteRune('\\')
+			sb.WriteRune(r)
+		case ' ':
+			// spaces separate key and value, and leading spaces in values are trimmed
+			if key || i == 0 {
+				sb.WriteRune('\\')
+			}
+			sb.WriteRune(r)
+		default:
+			if r < 0x20 || r > 0x7e {
+				for _, c := range utf16.Encode([]rune{r}) {
+					sb.WriteString(fmt.Sprintf(`\u%04X`, c))
+				}
+				continue
+			}
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
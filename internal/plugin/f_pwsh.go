@@ -5,14 +5,24 @@ package plugin
 
 import (
 	"fmt"
-	"log/slog"
 	"strings"
 
 	"github.com/withholm/polyenv/internal/model"
-	"github.com/withholm/polyenv/internal/tools"
 )
 
+// nothing is expanded in single quotes. a quote is escaped by doubling it,
+// and powershell also reads the typographic single quotes as quotes
+var pwshEscaper = strings.NewReplacer(`'`, `''`, "\u2018", "\u2018\u2018", "\u2019", "\u2019\u2019", "\u201a", "\u201a\u201a", "\u201b", "\u201b\u201b")
+
 type PwshFormatter struct {
+	//emit `Remove-Item env:KEY` to unload the values
+	Unset bool
+	//export as terraform input variables (TF_VAR_name)
+	TfVar bool
+}
+
+func pwshQuote(s string) string {
+	return "'" + pwshEscaper.Replace(s) + "'"
 }
 
 func (f *PwshFormatter) Name() string {
@@ -28,20 +38,21 @@ func (f *PwshFormatter) InputFormat(data []byte) (*model.InputData, error) {
 }
 
 func (f *PwshFormatter) OutputFormat(data []model.StoredEnv) ([]byte, error) {
+	if f.TfVar {
+		var err error
+		data, err = terraformEnv(data, "TF_VAR_")
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	var out []string
 	for _, v := range data {
-		var val string
-		//if value ir only numbers
-		if _, err := fmt.Sscanf(v.Value, "%d", &v.Value); err == nil {
-			val = v.Value
-		} else if ok, _ := tools.InequalFindInStrSlice([]string{"true", "false"}, v.Value); ok {
-			val = fmt.Sprintf("$%s", v.Value)
-		} else {
-			val = fmt.Sprintf("\"%s\"", v.Value)
+		if f.Unset {
+			out = append(out, fmt.Sprintf("Remove-Item %s -ErrorAction SilentlyContinue", pwshQuote("env:"+v.Key)))
+			continue
 		}
-
-		slog.Info("Setting", "env", v.Key)
-		out = append(out, fmt.Sprintf("Set-Item \"env:%s\" -value %s", v.Key, val))
+		out = append(out, fmt.Sprintf("Set-Item %s -value %s", pwshQuote("env:"+v.Key), pwshQuote(v.Value)))
 	}
 	return []byte(strings.Join(out, "\n")), nil
 }
@@ -0,0 +1,51 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package plugin
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/withholm/polyenv/internal/model"
+)
+
+// systemd reads these escaped inside double quotes. newlines are kept as is within the quotes
+var systemdEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	"`", "\\`",
+	`$`, `\$`,
+)
+
+// file for systemd's `EnvironmentFile=`. values are double quoted, so whitespace is kept
+type SystemdFormatter struct {
+}
+
+func (f *SystemdFormatter) Name() string {
+	return "systemd"
+}
+
+func (f *SystemdFormatter) Detect(data []byte) bool {
+	return false
+}
+
+func (f *SystemdFormatter) InputFormat(data []byte) (*model.InputData, error) {
+	return nil, nil
+}
+
+func (f *SystemdFormatter) OutputFormat(data []model.StoredEnv) ([]byte, error) {
+	var sb strings.Builder
+	for _, v := range data {
+		if !shellKeyRegex.MatchString(v.Key) {
+			return nil, fmt.Errorf("key '%s' is not a valid systemd environment variable name", v.Key)
+		}
+		// systemd refuses control characters except newline and tab
+		if strings.ContainsFunc(v.Value, func(r rune) bool { return unicode.IsControl(r) && r != '\n' && r != '\t' }) {
+			return nil, fmt.Errorf("value of %s contains control characters, which systemd does not accept", v.Key)
+		}
+		sb.WriteString(v.Key + `="` + systemdEscaper.Replace(v.Value) + "\"\n")
+	}
+	return []byte(sb.String()), nil
+}
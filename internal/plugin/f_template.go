@@ -0,0 +1,159 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package plugin
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/withholm/polyenv/internal/model"
+)
+
+// suffix of named templates stored next to the polyenv file ({name}.polyenv.tmpl)
+const TemplateSuffix = ".polyenv.tmpl"
+
+// renders the values with a go text/template. the template is given the list of values ([]model.StoredEnv)
+type TemplateFormatter struct {
+	//path to a template file, or the name of a template next to the polyenv file
+	Template string
+	//directory of the polyenv file, used to find named templates
+	Dir string
+}
+
+func (f *TemplateFormatter) Name() string {
+	return "template"
+}
+
+func (f *TemplateFormatter) Detect(data []byte) bool {
+	return false
+}
+
+func (f *TemplateFormatter) InputFormat(data []byte) (*model.InputData, error) {
+	return nil, nil
+}
+
+func (f *TemplateFormatter) OutputFormat(data []model.StoredEnv) ([]byte, error) {
+	path, err := f.resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := template.New(filepath.Base(path)).
+		Funcs(templateFuncs).
+		Option("missingkey=error").
+		ParseFiles(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// finds the template file. a path is used as is, a name is looked up as {dir}/{name}.polyenv.tmpl
+func (f *TemplateFormatter) resolve() (string, error) {
+	if f.Template == "" {
+		return "", fmt.Errorf("no template set. use --template with a path or the name of a template next to the polyenv file")
+	}
+
+	candidates := []string{f.Template}
+	if f.Dir != "" && !strings.ContainsAny(f.Template, `/\`) {
+		candidates = append(candidates, filepath.Join(f.Dir, strings.TrimSuffix(f.Template, TemplateSuffix)+TemplateSuffix))
+	}
+	for _, c := range candidates {
+		info, err := os.Stat(c)
+		if err == nil && !info.IsDir() {
+			return c, nil
+		}
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("failed to read template %s: %w", c, err)
+		}
+	}
+	if f.Dir != "" {
+		names, err := ListTemplates(f.Dir)
+		if err == nil && len(names) > 0 {
+			return "", fmt.Errorf("could not find template '%s'. looked in %v. available templates: %v", f.Template, candidates, names)
+		}
+	}
+	return "", fmt.Errorf("could not find template '%s'. looked in %v", f.Template, candidates)
+}
+
+// names of the templates next to the polyenv file
+func ListTemplates(dir string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*"+TemplateSuffix))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, 0, len(matches))
+	for _, m := range matches {
+		out = append(out, strings.TrimSuffix(filepath.Base(m), TemplateSuffix))
+	}
+	return out, nil
+}
+
+// functions available in templates
+var templateFuncs = template.FuncMap{
+	// "value" with go escaping
+	"quote": strconv.Quote,
+	// 'value' for posix shells
+	"squote": func(s string) string {
+		return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+	},
+	"b64enc": func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	},
+	"b64dec": func(s string) (string, error) {
+		b, err := base64.StdEncoding.DecodeString(s)
+		return string(b), err
+	},
+	// json string, including quotes
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+	"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+	"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+	"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+	// true if the value is from a vault or detected as a secret
+	"isSecret": templateIsSecret,
+	"secrets": func(data []model.StoredEnv) []model.StoredEnv {
+		return templateFilter(data, true)
+	},
+	"nonSecrets": func(data []model.StoredEnv) []model.StoredEnv {
+		return templateFilter(data, false)
+	},
+}
+
+func templateIsSecret(v model.StoredEnv) bool {
+	if v.IsSecret {
+		return true
+	}
+	s, _ := v.DetectSecret()
+	return s
+}
+
+func templateFilter(data []model.StoredEnv, secret bool) []model.StoredEnv {
+	out := make([]model.StoredEnv, 0, len(data))
+	for _, v := range data {
+		if templateIsSecret(v) == secret {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,107 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/withholm/polyenv/internal/model"
+)
+
+var terraformInvalidChars = regexp.MustCompile(`[^a-z0-9_-]`)
+
+// hcl string escapes. ${ and %{ starts templates in hcl, so they are escaped as well
+var hclEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\t", `\t`,
+	"${", "$${",
+	"%{", "%%{",
+)
+
+// terraform variable definitions. 'tfvars' writes hcl, 'tfvars-json' json.
+// keys are lowercased and sanitized to valid terraform variable names
+type TerraformFormatter struct {
+	JSON bool
+}
+
+func (f *TerraformFormatter) Name() string {
+	if f.JSON {
+		return "tfvars-json"
+	}
+	return "tfvars"
+}
+
+func (f *TerraformFormatter) Detect(data []byte) bool {
+	return false
+}
+
+func (f *TerraformFormatter) InputFormat(data []byte) (*model.InputData, error) {
+	return nil, nil
+}
+
+func (f *TerraformFormatter) OutputFormat(data []model.StoredEnv) ([]byte, error) {
+	vars, err := terraformEnv(data, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if f.JSON {
+		out := make(map[string]string, len(vars))
+		for _, v := range vars {
+			out[v.Key] = v.Value
+		}
+		b, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(b, '\n'), nil
+	}
+
+	// align '=' like terraform fmt does
+	width := 0
+	for _, v := range vars {
+		width = max(width, len(v.Key))
+	}
+	var sb strings.Builder
+	for _, v := range vars {
+		sb.WriteString(fmt.Sprintf("%-*s = \"%s\"\n", width, v.Key, hclEscaper.Replace(v.Value)))
+	}
+	return []byte(sb.String()), nil
+}
+
+// converts a key to a valid terraform variable name: lowercase, only a-z, 0-9, '_' and '-', not starting with a digit or '-'
+func terraformKey(key string) string {
+	out := terraformInvalidChars.ReplaceAllString(strings.ToLower(key), "_")
+	if out == "" || (out[0] >= '0' && out[0] <= '9') || out[0] == '-' {
+		out = "_" + out
+	}
+	return out
+}
+
+// returns the values with keys converted to terraform variable names, with prefix added.
+// renamed keys are logged, and it fails if two keys end up with the same name
+func terraformEnv(data []model.StoredEnv, prefix string) ([]model.StoredEnv, error) {
+	seen := make(map[string]string, len(data))
+	out := make([]model.StoredEnv, 0, len(data))
+	for _, v := range data {
+		key := prefix + terraformKey(v.Key)
+		if existing, ok := seen[key]; ok {
+			return nil, fmt.Errorf("both '%s' and '%s' becomes terraform variable '%s'", existing, v.Key, key)
+		}
+		seen[key] = v.Key
+		if key != prefix+v.Key {
+			slog.Info("renamed key for terraform", "key", v.Key, "variable", key)
+		}
+		v.Key = key
+		out = append(out, v)
+	}
+	return out, nil
+}
@@ -0,0 +1,101 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package plugin
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/withholm/polyenv/internal/model"
+)
+
+// toml document. keys are nested in tables by separator (DB__HOST -> [db] host) unless flat
+type TOMLFormatter struct {
+	//dont nest keys
+	Flat bool
+	//separator used to nest keys. defaults to '__'
+	Separator string
+}
+
+func (f *TOMLFormatter) Name() string {
+	if f.Flat {
+		return "toml-flat"
+	}
+	return "toml"
+}
+
+// only detects toml with tables or dotted keys. flat toml is mostly valid dotenv and is left to that formatter
+func (f *TOMLFormatter) Detect(data []byte) bool {
+	if len(data) == 0 {
+		return false
+	}
+	var out map[string]any
+	_, err := toml.Decode(string(data), &out)
+	if err != nil {
+		return false
+	}
+	for _, v := range out {
+		if _, ok := v.(map[string]any); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *TOMLFormatter) InputFormat(data []byte) (*model.InputData, error) {
+	var in map[string]any
+	_, err := toml.Decode(string(data), &in)
+	if err != nil {
+		return nil, err
+	}
+	out, err := flattenInput(in, f.Separator, !f.Flat)
+	if err != nil {
+		return nil, err
+	}
+	// dates and times are kept as written
+	for k, v := range out {
+		if t, ok := v.(time.Time); ok {
+			out[k] = tomlTimeString(t)
+		}
+	}
+	return &model.InputData{IsMap: true, Value: out}, nil
+}
+
+func (f *TOMLFormatter) OutputFormat(data []model.StoredEnv) ([]byte, error) {
+	var doc map[string]any
+	var err error
+	if f.Flat {
+		doc, err = flatEnv(data)
+	} else {
+		doc, err = nestEnv(data, f.Separator)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	enc := toml.NewEncoder(&buf)
+	enc.Indent = ""
+	err = enc.Encode(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode toml: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// formats toml date/time values the way they are written in toml
+func tomlTimeString(t time.Time) string {
+	// the toml package marks local dates and times with these zones
+	switch t.Location().String() {
+	case "date-local":
+		return t.Format("2006-01-02")
+	case "time-local":
+		return t.Format("15:04:05.999999999")
+	case "datetime-local":
+		return t.Format("2006-01-02T15:04:05.999999999")
+	}
+	return t.Format(time.RFC3339Nano)
+}
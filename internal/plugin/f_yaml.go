@@ -0,0 +1,89 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package plugin
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/withholm/polyenv/internal/model"
+	"gopkg.in/yaml.v3"
+)
+
+// yaml document. keys are nested by separator (DB__HOST -> db.host) unless flat
+type YAMLFormatter struct {
+	//dont nest keys
+	Flat bool
+	//separator used to nest keys. defaults to '__'
+	Separator string
+}
+
+func (f *YAMLFormatter) Name() string {
+	if f.Flat {
+		return "yaml-flat"
+	}
+	return "yaml"
+}
+
+// only detects yaml mappings, as any plain text is a valid yaml scalar
+func (f *YAMLFormatter) Detect(data []byte) bool {
+	if len(data) == 0 {
+		return false
+	}
+	var out map[string]any
+	err := yaml.Unmarshal(data, &out)
+	if err != nil || len(out) == 0 {
+		return false
+	}
+	// 'KEY=a: b' is a valid yaml mapping, but most likely dotenv
+	for k := range out {
+		if strings.Contains(k, "=") {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *YAMLFormatter) InputFormat(data []byte) (*model.InputData, error) {
+	var in map[string]any
+	err := yaml.Unmarshal(data, &in)
+	if err != nil {
+		return nil, err
+	}
+	out, err := flattenInput(in, f.Separator, !f.Flat)
+	if err != nil {
+		return nil, err
+	}
+	return &model.InputData{IsMap: true, Value: out}, nil
+}
+
+func (f *YAMLFormatter) OutputFormat(data []model.StoredEnv) ([]byte, error) {
+	var doc map[string]any
+	var err error
+	if f.Flat {
+		doc, err = flatEnv(data)
+	} else {
+		doc, err = nestEnv(data, f.Separator)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(doc) == 0 {
+		return []byte{}, nil
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	err = enc.Encode(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode yaml: %w", err)
+	}
+	err = enc.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
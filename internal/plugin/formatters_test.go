@@ -12,6 +12,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/withholm/polyenv/internal/model"
+	"github.com/withholm/polyenv/internal/tools"
 )
 
 // update is a command-line flag to update the golden files.
@@ -32,6 +33,15 @@ func TestFormatters(t *testing.T) {
 				{Key: "B", Value: "2", IsSecret: true},
 			},
 		},
+		{
+			name: "nested",
+			input: []model.StoredEnv{
+				{Key: "APP_NAME", Value: "polyenv", IsSecret: false},
+				{Key: "DB__HOST", Value: "localhost", IsSecret: false},
+				{Key: "DB__PORT", Value: "5432", IsSecret: false},
+				{Key: "DB__AUTH__PASSWORD", Value: "p@ss \"word\"", IsSecret: true},
+			},
+		},
 		// {
 		// 	name: "different values",
 		// 	input: []model.StoredEnv{
@@ -55,6 +65,37 @@ func TestFormatters(t *testing.T) {
 		{name: "azdevops", formatter: &AzDevopsFormatter{}},
 		{name: "pwsh", formatter: &PwshFormatter{}},
 		{name: "passthrough", formatter: &PassthroughFormatter{}},
+		{name: "yaml", formatter: &YAMLFormatter{}},
+		{name: "yaml_flat", formatter: &YAMLFormatter{Flat: true}},
+		{name: "toml", formatter: &TOMLFormatter{}},
+		{name: "toml_flat", formatter: &TOMLFormatter{Flat: true}},
+		{name: "k8s_secret", formatter: &K8sFormatter{Kind: K8sSecret}},
+		{name: "k8s_configmap", formatter: &K8sFormatter{Kind: K8sConfigMap, ResourceName: "app", Namespace: "dev", Labels: map[string]string{"app.kubernetes.io/name": "app"}}},
+		{name: "k8s_stream", formatter: &K8sFormatter{Kind: K8sSecret, Stream: true}},
+		{name: "posix", formatter: &PosixFormatter{}},
+		{name: "fish", formatter: &FishFormatter{}},
+		{name: "nu", formatter: &NuFormatter{}},
+		{name: "cmd", formatter: &CmdFormatter{}},
+		{name: "posix_unset", formatter: &PosixFormatter{Unset: true}},
+		{name: "pwsh_unset", formatter: &PwshFormatter{Unset: true}},
+		{name: "fish_unset", formatter: &FishFormatter{Unset: true}},
+		{name: "nu_unset", formatter: &NuFormatter{Unset: true}},
+		{name: "cmd_unset", formatter: &CmdFormatter{Unset: true}},
+		{name: "docker_env", formatter: &DockerEnvFormatter{}},
+		{name: "systemd", formatter: &SystemdFormatter{}},
+		{name: "compose", formatter: &ComposeFormatter{}},
+		{name: "compose_service", formatter: &ComposeFormatter{Service: "app"}},
+		{name: "tfvars", formatter: &TerraformFormatter{}},
+		{name: "tfvars_json", formatter: &TerraformFormatter{JSON: true}},
+		{name: "posix_tf_var", formatter: &PosixFormatter{TfVar: true}},
+		{name: "pwsh_tf_var", formatter: &PwshFormatter{TfVar: true}},
+		{name: "properties", formatter: &PropertiesFormatter{}},
+		{name: "appsettings", formatter: &AppSettingsFormatter{}},
+		{name: "launchsettings", formatter: &LaunchSettingsFormatter{Profile: "dev"}},
+		{name: "vscode", formatter: &VSCodeFormatter{}},
+		{name: "github", formatter: &GithubFormatter{}},
+		{name: "gitlab", formatter: &GitlabFormatter{}},
+		{name: "jenkins", formatter: &JenkinsFormatter{}},
 		// Note: 'pick' and 'stats' formatters are excluded as they are special cases.
 	}
 
@@ -93,3 +134,373 @@ func TestFormatters(t *testing.T) {
 		})
 	}
 }
+
+func TestNestedFormatters(t *testing.T) {
+	formatters := []model.Formatter{&YAMLFormatter{}, &TOMLFormatter{}}
+
+	for _, f := range formatters {
+		t.Run(f.Name(), func(t *testing.T) {
+			input := []model.StoredEnv{
+				{Key: "APP", Value: "polyenv"},
+				{Key: "DB__HOST", Value: "localhost"},
+				{Key: "DB__PORT", Value: "5432"},
+			}
+			out, err := f.OutputFormat(input)
+			if err != nil {
+				t.Fatalf("OutputFormat() returned an error: %v", err)
+			}
+			if !f.Detect(out) {
+				t.Fatalf("expected formatter to detect its own output:\n%s", out)
+			}
+			in, err := f.InputFormat(out)
+			if err != nil {
+				t.Fatalf("InputFormat() returned an error: %v", err)
+			}
+			got, err := InputToEnv(in)
+			if err != nil {
+				t.Fatalf("InputToEnv() returned an error: %v", err)
+			}
+			if diff := cmp.Diff(input, got); diff != "" {
+				t.Errorf("round trip mismatch (-want +got):\n%s", diff)
+			}
+
+			conflicts := [][]model.StoredEnv{
+				{{Key: "DB", Value: "1"}, {Key: "DB__HOST", Value: "2"}},
+				{{Key: "DB__HOST", Value: "2"}, {Key: "DB", Value: "1"}},
+				{{Key: "DB____HOST", Value: "1"}},
+			}
+			for _, c := range conflicts {
+				_, err = f.OutputFormat(c)
+				if err == nil {
+					t.Errorf("expected error when nesting %v", c)
+				}
+			}
+		})
+	}
+}
+
+func TestK8sFormatterErrors(t *testing.T) {
+	testCases := []struct {
+		name      string
+		formatter *K8sFormatter
+		input     []model.StoredEnv
+	}{
+		{name: "invalid key", formatter: &K8sFormatter{Kind: K8sSecret}, input: []model.StoredEnv{{Key: "A B", Value: "1"}}},
+		{name: "invalid name", formatter: &K8sFormatter{Kind: K8sSecret, ResourceName: "My_App"}},
+		{name: "invalid namespace", formatter: &K8sFormatter{Kind: K8sConfigMap, Namespace: "Dev"}},
+		{name: "unknown kind", formatter: &K8sFormatter{Kind: "Pod"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.formatter.OutputFormat(tc.input)
+			if err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func TestK8sName(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected string
+	}{
+		{name: "dev", expected: "dev"},
+		{name: "Dev", expected: "dev"},
+		{name: "My_App env", expected: "my-app-env"},
+		{name: "_prod.EU_", expected: "prod.eu"},
+		{name: "__", expected: "polyenv"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := K8sName(tc.name)
+			if got != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+			// the name has to be accepted by the formatter
+			f := &K8sFormatter{Kind: K8sSecret, ResourceName: got}
+			if _, err := f.OutputFormat(nil); err != nil {
+				t.Errorf("expected %q to be a valid name: %v", got, err)
+			}
+		})
+	}
+}
+
+func TestGitlabFormatterErrors(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input []model.StoredEnv
+	}{
+		{name: "multiline", input: []model.StoredEnv{{Key: "A", Value: "1\n2"}}},
+		{name: "invalid key", input: []model.StoredEnv{{Key: "A-B", Value: "1"}}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := (&GitlabFormatter{}).OutputFormat(tc.input)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestGithubFormatterMultiline(t *testing.T) {
+	orig := githubDelimiter
+	defer func() { githubDelimiter = orig }()
+	githubDelimiter = func() (string, error) { return "EOF_TEST", nil }
+
+	out, err := (&GithubFormatter{}).OutputFormat([]model.StoredEnv{
+		{Key: "A", Value: "1"},
+		{Key: "PEM", Value: "line1\nline2"},
+	})
+	if err != nil {
+		t.Fatalf("OutputFormat() returned an error: %v", err)
+	}
+	expected := "A=1\nPEM<<EOF_TEST\nline1\nline2\nEOF_TEST\n"
+	if diff := cmp.Diff(expected, string(out)); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+
+	githubDelimiter = func() (string, error) { return "line2", nil }
+	_, err = (&GithubFormatter{}).OutputFormat([]model.StoredEnv{{Key: "PEM", Value: "line1\nline2"}})
+	if err == nil {
+		t.Error("expected error when value contains the delimiter")
+	}
+
+	// default delimiter is random
+	a, _ := orig()
+	b, _ := orig()
+	if a == b {
+		t.Errorf("expected random delimiters, got %s twice", a)
+	}
+}
+
+func TestShellFormatterEscaping(t *testing.T) {
+	input := []model.StoredEnv{{Key: "A", Value: "it's a \\ \"test\"\nline2"}}
+	testCases := []struct {
+		name      string
+		formatter model.Formatter
+		input     []model.StoredEnv
+		expected  string
+		expectErr bool
+	}{
+		{name: "fish", formatter: &FishFormatter{}, expected: "set -gx A 'it\\'s a \\\\ \"test\"\nline2'\n"},
+		{name: "pwsh", formatter: &PwshFormatter{}, expected: "Set-Item 'env:A' -value 'it''s a \\ \"test\"\nline2'"},
+		{name: "pwsh expansion", formatter: &PwshFormatter{}, input: []model.StoredEnv{{Key: "A", Value: "$HOME `id` \u2019"}}, expected: "Set-Item 'env:A' -value '$HOME `id` \u2019\u2019'"},
+		{name: "pwsh unset", formatter: &PwshFormatter{Unset: true}, input: []model.StoredEnv{{Key: "A'B", Value: "1"}}, expected: "Remove-Item 'env:A''B' -ErrorAction SilentlyContinue"},
+		{name: "nu", formatter: &NuFormatter{}, expected: "$env.A = \"it's a \\\\ \\\"test\\\"\\nline2\"\n"},
+		{name: "cmd", formatter: &CmdFormatter{}, expectErr: true},
+		{name: "docker-env", formatter: &DockerEnvFormatter{}, expectErr: true},
+		{name: "docker-env literal", formatter: &DockerEnvFormatter{}, input: []model.StoredEnv{{Key: "A", Value: ` "quoted" $HOME `}}, expected: "A= \"quoted\" $HOME \n"},
+		{name: "docker-env invalid key", formatter: &DockerEnvFormatter{}, input: []model.StoredEnv{{Key: "#A", Value: "1"}}, expectErr: true},
+		{name: "systemd", formatter: &SystemdFormatter{}, expected: "A=\"it's a \\\\ \\\"test\\\"\nline2\"\n"},
+		{name: "systemd expansion", formatter: &SystemdFormatter{}, input: []model.StoredEnv{{Key: "A", Value: "$HOME `id`"}}, expected: "A=\"\\$HOME \\`id\\`\"\n"},
+		{name: "systemd control", formatter: &SystemdFormatter{}, input: []model.StoredEnv{{Key: "A", Value: "a\x00b"}}, expectErr: true},
+		{name: "jenkins", formatter: &JenkinsFormatter{}, expected: "env['A'] = 'it\\'s a \\\\ \"test\"\\nline2'\n"},
+		{name: "jenkins invalid key", formatter: &JenkinsFormatter{}, input: []model.StoredEnv{{Key: "A-B", Value: "1"}}, expectErr: true},
+		{name: "compose", formatter: &ComposeFormatter{}, input: []model.StoredEnv{{Key: "A", Value: "$HOME"}}, expected: "environment:\n  A: $$HOME\n"},
+		{name: "cmd quotes", formatter: &CmdFormatter{}, input: []model.StoredEnv{{Key: "A", Value: `a "b&c" d&e`}}, expected: "@echo off\r\nset \"A=a \"b^&c\" d&e\"\r\n"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			in := input
+			if tc.input != nil {
+				in = tc.input
+			}
+			out, err := tc.formatter.OutputFormat(in)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("OutputFormat() returned an error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, string(out)); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	out, err := (&CmdFormatter{}).OutputFormat([]model.StoredEnv{{Key: "A", Value: "100% & <ok>"}})
+	if err != nil {
+		t.Fatalf("OutputFormat() returned an error: %v", err)
+	}
+	if !strings.Contains(string(out), "set \"A=100%% & <ok>\"\r\n") {
+		t.Errorf("unexpected cmd output %q", string(out))
+	}
+}
+
+func TestTerraformKeys(t *testing.T) {
+	testCases := []struct {
+		key      string
+		expected string
+	}{
+		{key: "DB_HOST", expected: "db_host"},
+		{key: "app.name", expected: "app_name"},
+		{key: "my-var", expected: "my-var"},
+		{key: "1PASSWORD", expected: "_1password"},
+		{key: "-X", expected: "_-x"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.key, func(t *testing.T) {
+			if got := terraformKey(tc.key); got != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, got)
+			}
+		})
+	}
+
+	_, err := terraformEnv([]model.StoredEnv{{Key: "A_B"}, {Key: "a.b"}}, "")
+	if err == nil {
+		t.Error("expected error on colliding keys")
+	}
+
+	out, err := (&TerraformFormatter{}).OutputFormat([]model.StoredEnv{{Key: "TPL", Value: "${var.x} %{if} \"q\"\n"}})
+	if err != nil {
+		t.Fatalf("OutputFormat() returned an error: %v", err)
+	}
+	expected := "tpl = \"$${var.x} %%{if} \\\"q\\\"\\n\"\n"
+	if diff := cmp.Diff(expected, string(out)); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestPropertiesEscape(t *testing.T) {
+	out, err := (&PropertiesFormatter{}).OutputFormat([]model.StoredEnv{
+		{Key: "APP__GREETING", Value: " héllo wörld 😀\n#!=:\\"},
+		{Key: "KEY WITH SPACE", Value: "x"},
+	})
+	if err != nil {
+		t.Fatalf("OutputFormat() returned an error: %v", err)
+	}
+	expected := "app.greeting=\\ h\\u00E9llo w\\u00F6rld \\uD83D\\uDE00\\n\\#\\!\\=\\:\\\\\n" +
+		"key\\ with\\ space=x\n"
+	if diff := cmp.Diff(expected, string(out)); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+
+	_, err = (&PropertiesFormatter{}).OutputFormat([]model.StoredEnv{{Key: "DB__HOST"}, {Key: "db__host"}})
+	if err == nil {
+		t.Error("expected error on colliding keys")
+	}
+}
+
+func TestTemplateFormatter(t *testing.T) {
+	input := []model.StoredEnv{
+		{Key: "APP_NAME", Value: "polyenv"},
+		{Key: "TOKEN", Value: "it's", IsSecret: true},
+	}
+	expected := "# generated by polyenv\napp_name: \"polyenv\"\nsecrets:\n  TOKEN: aXQncw== # 'it'\\''s'\n"
+
+	testCases := []struct {
+		name      string
+		formatter *TemplateFormatter
+		expectErr bool
+	}{
+		{name: "path", formatter: &TemplateFormatter{Template: filepath.Join("testdata", "templates", "app.polyenv.tmpl")}},
+		{name: "name next to polyenv file", formatter: &TemplateFormatter{Template: "app", Dir: filepath.Join("testdata", "templates")}},
+		{name: "missing", formatter: &TemplateFormatter{Template: "missing", Dir: filepath.Join("testdata", "templates")}, expectErr: true},
+		{name: "not set", formatter: &TemplateFormatter{}, expectErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := tc.formatter.OutputFormat(input)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("OutputFormat() returned an error: %v", err)
+			}
+			if diff := cmp.Diff(expected, string(out)); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	// resolved by name, like export does
+	fmtFunc, ok := tools.InequalFindInMap(OutputFormatters, "template")
+	if !ok {
+		t.Fatal("template is not registered in OutputFormatters")
+	}
+	tf, ok := fmtFunc().(*TemplateFormatter)
+	if !ok {
+		t.Fatalf("expected *TemplateFormatter, got %T", fmtFunc())
+	}
+	tf.Template = "app"
+	tf.Dir = filepath.Join("testdata", "templates")
+	out, err := tf.OutputFormat(input)
+	if err != nil {
+		t.Fatalf("OutputFormat() returned an error: %v", err)
+	}
+	if diff := cmp.Diff(expected, string(out)); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+	if !AcceptsFormat(&StdOutWriter{}, "template") {
+		t.Error("expected stdout to accept template")
+	}
+
+	names, err := ListTemplates(filepath.Join("testdata", "templates"))
+	if err != nil {
+		t.Fatalf("ListTemplates() returned an error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"app"}, names); diff != "" {
+		t.Errorf("templates mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	root := filepath.Join("/", "repo")
+	data := []model.StoredEnv{
+		{Key: "DB_HOST", Value: "deep", File: filepath.Join(root, "sub", ".env.dev")},
+		{Key: "DB_HOST", Value: "root", File: filepath.Join(root, ".env.dev")},
+		{Key: "EMPTY", Value: "", File: filepath.Join(root, ".env.dev")},
+		{Key: "Token", Value: "x", File: filepath.Join(root, ".env.dev")},
+		{Key: "TOKEN", Value: "y", File: filepath.Join(root, ".env.dev")},
+		{Key: "API", Value: "from-vault", File: filepath.Join(root, ".env.secret.dev")},
+		{Key: "DB_PASS", Value: "polyenv://kv2/db-pass", File: filepath.Join(root, ".env.dev")},
+	}
+	secrets := map[string]model.Secret{
+		"API":     {Vault: "kv", LocalKey: "API"},
+		"MISSING": {Vault: "kv", LocalKey: "MISSING"},
+	}
+
+	report := Inspect(data, secrets, root)
+
+	expected := []InspectEntry{
+		{Key: "API", Tags: []string{"sec"}, File: ".env.secret.dev", Vault: "kv"},
+		{Key: "DB_HOST", Tags: []string{"dup", "shadowed"}, File: filepath.Join("sub", ".env.dev"), ShadowedBy: ".env.dev"},
+		{Key: "DB_HOST", Tags: []string{"dup"}, File: ".env.dev"},
+		{Key: "DB_PASS", Tags: []string{"sec"}, File: ".env.dev", Vault: "kv2"},
+		{Key: "EMPTY", Tags: []string{"empty"}, File: ".env.dev"},
+		{Key: "MISSING", Tags: []string{"sec", "missing"}, Vault: "kv"},
+		{Key: "TOKEN", Tags: []string{"case"}, File: ".env.dev"},
+		{Key: "Token", Tags: []string{"case"}, File: ".env.dev"},
+	}
+	if diff := cmp.Diff(expected, report.Entries); diff != "" {
+		t.Errorf("entries mismatch (-want +got):\n%s", diff)
+	}
+
+	expectedSummary := InspectSummary{Keys: 6, Values: 7, Secrets: 2, Empty: 1, Shadowed: 1, MissingSecrets: 1, CaseCollisions: 1}
+	if diff := cmp.Diff(expectedSummary, report.Summary); diff != "" {
+		t.Errorf("summary mismatch (-want +got):\n%s", diff)
+	}
+
+	out, err := report.csv()
+	if err != nil {
+		t.Fatalf("csv() returned an error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if lines[0] != "key,tags,file,vault,secret_reason,shadowed_by" || len(lines) != len(expected)+1 {
+		t.Errorf("unexpected csv output:\n%s", out)
+	}
+	if !strings.Contains(string(out), "DB_HOST,\"dup,shadowed\","+filepath.Join("sub", ".env.dev")+",,,.env.dev") {
+		t.Errorf("csv missing shadowed row:\n%s", out)
+	}
+	if !strings.Contains(string(report.table()), "6 keys (7 values), 2 secrets, 1 empty, 1 shadowed, 1 missing secrets, 1 case collisions") {
+		t.Errorf("table missing summary footer")
+	}
+}
@@ -0,0 +1,132 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package plugin
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/withholm/polyenv/internal/model"
+)
+
+// default separator used to nest keys. DB__HOST -> db.host
+const DefaultNestSeparator = "__"
+
+// converts env values to a nested map by splitting keys on separator. keys are lowercased.
+// fails if a key is both a value and a parent of other keys (DB and DB__HOST)
+func nestEnv(data []model.StoredEnv, separator string) (map[string]any, error) {
+	return nestEnvCase(data, separator, true)
+}
+
+// same as nestEnv, but only lowercases keys if lower is set
+func nestEnvCase(data []model.StoredEnv, separator string, lower bool) (map[string]any, error) {
+	if separator == "" {
+		separator = DefaultNestSeparator
+	}
+
+	out := make(map[string]any)
+	// keep track of what key created each path so errors make sense
+	origin := make(map[string]string)
+	for _, env := range data {
+		key := env.Key
+		if lower {
+			key = strings.ToLower(key)
+			separator = strings.ToLower(separator)
+		}
+		parts := strings.Split(key, separator)
+		for _, p := range parts {
+			if p == "" {
+				return nil, fmt.Errorf("cannot nest key %s: empty part when splitting on '%s'", env.Key, separator)
+			}
+		}
+
+		current := out
+		for i, part := range parts {
+			path := strings.Join(parts[:i+1], ".")
+			last := i == len(parts)-1
+			existing, ok := current[part]
+			if last {
+				if ok {
+					return nil, fmt.Errorf("cannot nest key %s: %s is already defined by %s", env.Key, path, origin[path])
+				}
+				current[part] = env.Value
+				origin[path] = env.Key
+				continue
+			}
+
+			if !ok {
+				child := make(map[string]any)
+				current[part] = child
+				origin[path] = env.Key
+				current = child
+				continue
+			}
+			child, isMap := existing.(map[string]any)
+			if !isMap {
+				return nil, fmt.Errorf("cannot nest key %s: %s is a value defined by %s", env.Key, path, origin[path])
+			}
+			current = child
+		}
+	}
+	return out, nil
+}
+
+// converts env values to a flat map
+func flatEnv(data []model.StoredEnv) (map[string]any, error) {
+	out := make(map[string]any)
+	for _, env := range data {
+		if _, ok := out[env.Key]; ok {
+			return nil, fmt.Errorf("key %s is defined multiple times", env.Key)
+		}
+		out[env.Key] = env.Value
+	}
+	return out, nil
+}
+
+// flattens a decoded document to a map of env values. nested keys are joined by separator.
+// if upper is set, keys are uppercased (db.host -> DB__HOST)
+func flattenInput(in map[string]any, separator string, upper bool) (map[string]any, error) {
+	if separator == "" {
+		separator = DefaultNestSeparator
+	}
+	out := make(map[string]any)
+	err := flattenInto(out, "", in, separator, upper)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func flattenInto(out map[string]any, prefix string, in map[string]any, separator string, upper bool) error {
+	keys := make([]string, 0, len(in))
+	for k := range in {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		key := k
+		if upper {
+			key = strings.ToUpper(k)
+		}
+		if prefix != "" {
+			key = prefix + separator + key
+		}
+
+		switch v := in[k].(type) {
+		case map[string]any:
+			err := flattenInto(out, key, v, separator, upper)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if _, ok := out[key]; ok {
+			return fmt.Errorf("key %s is defined multiple times", key)
+		}
+		out[key] = in[k]
+	}
+	return nil
+}
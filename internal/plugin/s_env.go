@@ -0,0 +1,44 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package plugin
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/withholm/polyenv/internal/model"
+)
+
+// reads the environment of the current process.
+// 'env:PREFIX' only returns variables starting with PREFIX
+type EnvSource struct{}
+
+func (s *EnvSource) Name() string {
+	return "env"
+}
+
+func (s *EnvSource) Detect(arg string) bool {
+	return arg == "env" || strings.HasPrefix(arg, "env:")
+}
+
+func (s *EnvSource) Get(arg string) ([]model.StoredEnv, error) {
+	_, prefix, _ := strings.Cut(arg, ":")
+	out := make([]model.StoredEnv, 0)
+	for _, e := range os.Environ() {
+		k, v, ok := strings.Cut(e, "=")
+		// windows has some special variables starting with '='
+		if !ok || k == "" {
+			continue
+		}
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		out = append(out, model.StoredEnv{Key: k, Value: v})
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Key < out[j].Key
+	})
+	return out, nil
+}
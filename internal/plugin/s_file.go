@@ -0,0 +1,45 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package plugin
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/withholm/polyenv/internal/model"
+)
+
+// reads a file. format is detected using input formatters.
+// accepts either a path or 'file:path'
+type FileSource struct{}
+
+func (s *FileSource) Name() string {
+	return "file"
+}
+
+func (s *FileSource) Detect(arg string) bool {
+	stat, err := os.Stat(s.path(arg))
+	return err == nil && !stat.IsDir()
+}
+
+func (s *FileSource) Get(arg string) ([]model.StoredEnv, error) {
+	path := s.path(arg)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	out, err := ParseInput(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for i := range out {
+		out[i].File = path
+	}
+	return out, nil
+}
+
+func (s *FileSource) path(arg string) string {
+	return strings.TrimPrefix(arg, "file:")
+}
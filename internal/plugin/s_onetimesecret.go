@@ -0,0 +1,154 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/withholm/polyenv/internal/model"
+	"github.com/withholm/polyenv/internal/tools"
+	"github.com/withholm/polyenv/internal/tui"
+)
+
+const (
+	// default onetimesecret instance
+	otsDefaultURL = "https://eu.onetimesecret.com"
+	// override base url, ie for self hosted instances
+	otsEnvURL = "POLYENV_OTS_URL"
+	// passphrase used to reveal secrets, if not given any other way
+	otsEnvPassphrase = "POLYENV_OTS_PASSPHRASE"
+)
+
+// reveals a secret shared with onetimesecret.
+// accepts the share url (https://{host}/secret/{key}) or 'ots:{key}'
+type OneTimeSecretSource struct {
+	//base url of the instance used for 'ots:{key}'. defaults to POLYENV_OTS_URL, POLYENV_OTS_REGION or eu.onetimesecret.com
+	BaseURL string
+	//passphrase of the secret. defaults to POLYENV_OTS_PASSPHRASE. will be asked for if needed and not set
+	Passphrase string
+}
+
+type otsRevealRequest struct {
+	Passphrase string `json:"passphrase,omitempty"`
+	Continue   bool   `json:"continue"`
+}
+
+type otsRevealResponse struct {
+	Success bool `json:"success"`
+	Record  struct {
+		SecretValue string `json:"secret_value"`
+	} `json:"record"`
+}
+
+func (s *OneTimeSecretSource) Name() string {
+	return "ots"
+}
+
+func (s *OneTimeSecretSource) Detect(arg string) bool {
+	_, _, err := s.parse(arg)
+	return err == nil
+}
+
+func (s *OneTimeSecretSource) Get(arg string) ([]model.StoredEnv, error) {
+	base, key, err := s.parse(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	passphrase := s.Passphrase
+	if passphrase == "" {
+		passphrase = os.Getenv(otsEnvPassphrase)
+	}
+
+	value, err := s.reveal(base, key, passphrase)
+	// secret might be protected by a passphrase. ask and try again
+	if err != nil && passphrase == "" && otsNeedsPassphrase(err) && tui.IsTTY() {
+		slog.Debug("failed to reveal without passphrase, asking for one", "error", err)
+		tui.RunHuh(huh.NewForm(
+			huh.NewGroup(
+				huh.NewInput().
+					Title("Passphrase").
+					Description("the secret could not be revealed without passphrase").
+					EchoMode(huh.EchoModePassword).
+					Value(&passphrase),
+			),
+		))
+		if passphrase != "" {
+			value, err = s.reveal(base, key, passphrase)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseInput([]byte(value))
+}
+
+// reveals the secret. this will burn it on the server
+func (s *OneTimeSecretSource) reveal(base, key, passphrase string) (string, error) {
+	endpoint := fmt.Sprintf("%s/api/v2/secret/%s/reveal", base, url.PathEscape(key))
+	slog.Debug("revealing secret", "url", endpoint)
+
+	resp := otsRevealResponse{}
+	err := tools.NewPolyenvHTTPClient().Post(context.Background(), endpoint, otsRevealRequest{
+		Passphrase: passphrase,
+		Continue:   true,
+	}, &resp)
+	if err != nil {
+		return "", fmt.Errorf("failed to reveal secret: %w", err)
+	}
+	if !resp.Success || resp.Record.SecretValue == "" {
+		return "", fmt.Errorf("failed to reveal secret: no value returned")
+	}
+	return resp.Record.SecretValue, nil
+}
+
+// true if the reveal failed in a way a passphrase could fix.
+// onetimesecret answers 404 for a missing or wrong passphrase, same as for an unknown secret
+func otsNeedsPassphrase(err error) bool {
+	var statusErr *tools.StatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	switch statusErr.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+		return true
+	}
+	return false
+}
+
+// returns base url and secret key from argument
+func (s *OneTimeSecretSource) parse(arg string) (string, string, error) {
+	if key, ok := strings.CutPrefix(arg, "ots:"); ok {
+		if key == "" {
+			return "", "", fmt.Errorf("missing secret key")
+		}
+		base, err := otsResolveURL(s.BaseURL, "")
+		if err != nil {
+			return "", "", err
+		}
+		return base, key, nil
+	}
+
+	u, err := url.Parse(arg)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return "", "", fmt.Errorf("'%s' is not a onetimesecret url", arg)
+	}
+
+	// share links are {base}/secret/{key}. base may include a path for self hosted instances
+	prefix, key, ok := strings.Cut(u.Path, "/secret/")
+	key = strings.Trim(key, "/")
+	if !ok || key == "" || strings.Contains(key, "/") {
+		return "", "", fmt.Errorf("'%s' is not a onetimesecret share url. expected {host}/secret/{key}", arg)
+	}
+	return fmt.Sprintf("%s://%s%s", u.Scheme, u.Host, prefix), key, nil
+}
@@ -0,0 +1,46 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package plugin
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/withholm/polyenv/internal/model"
+)
+
+// reads piped data from stdin. format is detected using input formatters
+type StdinSource struct {
+	//defaults to os.Stdin
+	Reader io.Reader
+}
+
+func (s *StdinSource) Name() string {
+	return "stdin"
+}
+
+func (s *StdinSource) Detect(arg string) bool {
+	return arg == "stdin" || arg == "-"
+}
+
+func (s *StdinSource) Get(arg string) ([]model.StoredEnv, error) {
+	reader := s.Reader
+	if reader == nil {
+		stat, err := os.Stdin.Stat()
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat stdin: %w", err)
+		}
+		if stat.Mode()&os.ModeCharDevice != 0 {
+			return nil, fmt.Errorf("nothing piped to stdin")
+		}
+		reader = os.Stdin
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stdin: %w", err)
+	}
+	return ParseInput(data)
+}
@@ -0,0 +1,290 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/withholm/polyenv/internal/model"
+	"github.com/withholm/polyenv/internal/tools"
+)
+
+func TestParseInput(t *testing.T) {
+	testCases := []struct {
+		name      string
+		input     string
+		expect    []model.StoredEnv
+		expectErr bool
+	}{
+		{
+			name:   "json",
+			input:  `{"B": "2", "A": "1", "C": 3, "D": null}`,
+			expect: []model.StoredEnv{{Key: "A", Value: "1"}, {Key: "B", Value: "2"}, {Key: "C", Value: "3"}, {Key: "D", Value: ""}},
+		},
+		{
+			name:   "json array of objects",
+			input:  `[{"key": "A", "value": "1"}, {"key": "B", "value": "2"}]`,
+			expect: []model.StoredEnv{{Key: "A", Value: "1"}, {Key: "B", Value: "2"}},
+		},
+		{
+			name:   "json array of strings",
+			input:  `["B=2", "A=1=1"]`,
+			expect: []model.StoredEnv{{Key: "A", Value: "1=1"}, {Key: "B", Value: "2"}},
+		},
+		{
+			name:   "dotenv",
+			input:  "B=2\n# comment\nA=\"1\"\n",
+			expect: []model.StoredEnv{{Key: "A", Value: "1"}, {Key: "B", Value: "2"}},
+		},
+		{
+			name:   "yaml",
+			input:  "app_name: polyenv\ndb:\n  host: localhost\n  port: 5432\n  tags: [a, b]\n",
+			expect: []model.StoredEnv{{Key: "APP_NAME", Value: "polyenv"}, {Key: "DB__HOST", Value: "localhost"}, {Key: "DB__PORT", Value: "5432"}, {Key: "DB__TAGS", Value: `["a","b"]`}},
+		},
+		{
+			name:   "toml",
+			input:  "app_name = \"polyenv\"\n[db]\nhost = \"localhost\"\nport = 5432\ncreated = 2024-01-02\n",
+			expect: []model.StoredEnv{{Key: "APP_NAME", Value: "polyenv"}, {Key: "DB__CREATED", Value: "2024-01-02"}, {Key: "DB__HOST", Value: "localhost"}, {Key: "DB__PORT", Value: "5432"}},
+		},
+		{
+			name:   "flat toml is dotenv",
+			input:  "A = \"1\"\n",
+			expect: []model.StoredEnv{{Key: "A", Value: "1"}},
+		},
+		{
+			name:   "empty",
+			input:  "  \n",
+			expect: []model.StoredEnv{},
+		},
+		{
+			name:      "invalid array item",
+			input:     `["A"]`,
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseInput([]byte(tc.input))
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseInput() returned an error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expect, got); diff != "" {
+				t.Errorf("ParseInput() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSources(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "values.json")
+	err := os.WriteFile(path, []byte(`{"A": "1"}`), 0o600)
+	if err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	t.Setenv("POLYENV_TEST_A", "1")
+
+	testCases := []struct {
+		name         string
+		arg          string
+		source       model.Source
+		expectSource string
+		expect       []model.StoredEnv
+	}{
+		{
+			name:         "stdin",
+			arg:          "stdin",
+			source:       &StdinSource{Reader: strings.NewReader("A=1\n")},
+			expectSource: "stdin",
+			expect:       []model.StoredEnv{{Key: "A", Value: "1"}},
+		},
+		{
+			name:         "file",
+			arg:          path,
+			source:       &FileSource{},
+			expectSource: "file",
+			expect:       []model.StoredEnv{{Key: "A", Value: "1", File: path}},
+		},
+		{
+			name:         "file prefix",
+			arg:          "file:" + path,
+			source:       &FileSource{},
+			expectSource: "file",
+			expect:       []model.StoredEnv{{Key: "A", Value: "1", File: path}},
+		},
+		{
+			name:         "env",
+			arg:          "env:POLYENV_TEST_",
+			source:       &EnvSource{},
+			expectSource: "env",
+			expect:       []model.StoredEnv{{Key: "POLYENV_TEST_A", Value: "1"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			src, err := FindSource(tc.arg)
+			if err != nil {
+				t.Fatalf("FindSource() returned an error: %v", err)
+			}
+			if src.Name() != tc.expectSource {
+				t.Errorf("expected source %s, got %s", tc.expectSource, src.Name())
+			}
+
+			got, err := tc.source.Get(tc.arg)
+			if err != nil {
+				t.Fatalf("Get() returned an error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expect, got); diff != "" {
+				t.Errorf("Get() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	_, err = FindSource(filepath.Join(dir, "missing.json"))
+	if err == nil {
+		t.Errorf("expected error for missing file")
+	}
+}
+
+func TestOneTimeSecretSource(t *testing.T) {
+	secrets := map[string]struct {
+		value      string
+		passphrase string
+	}{
+		"abc123": {value: "A=1\nB=2\n"},
+		"json":   {value: `{"A": "1"}`},
+		"locked": {value: "A=1", passphrase: "hunter2"},
+		"empty":  {value: ""},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v2/secret/{key}/reveal", func(w http.ResponseWriter, r *http.Request) {
+		var req otsRevealRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		sec, ok := secrets[r.PathValue("key")]
+		if !ok || sec.passphrase != req.Passphrase {
+			http.Error(w, `{"message":"Unknown secret"}`, http.StatusNotFound)
+			return
+		}
+		resp := otsRevealResponse{Success: true}
+		resp.Record.SecretValue = sec.value
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	testCases := []struct {
+		name       string
+		arg        string
+		passphrase string
+		expect     []model.StoredEnv
+		expectErr  bool
+	}{
+		{name: "share url", arg: srv.URL + "/secret/abc123", expect: []model.StoredEnv{{Key: "A", Value: "1"}, {Key: "B", Value: "2"}}},
+		{name: "key", arg: "ots:json", expect: []model.StoredEnv{{Key: "A", Value: "1"}}},
+		{name: "passphrase", arg: "ots:locked", passphrase: "hunter2", expect: []model.StoredEnv{{Key: "A", Value: "1"}}},
+		{name: "wrong passphrase", arg: "ots:locked", passphrase: "nope", expectErr: true},
+		{name: "unknown", arg: "ots:missing", expectErr: true},
+		{name: "empty value", arg: "ots:empty", expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			src := &OneTimeSecretSource{BaseURL: srv.URL, Passphrase: tc.passphrase}
+			if !src.Detect(tc.arg) {
+				t.Fatalf("expected source to detect %s", tc.arg)
+			}
+			got, err := src.Get(tc.arg)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Get() returned an error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expect, got); diff != "" {
+				t.Errorf("Get() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestOtsNeedsPassphrase(t *testing.T) {
+	testCases := []struct {
+		name   string
+		err    error
+		expect bool
+	}{
+		{name: "not found", err: &tools.StatusError{StatusCode: http.StatusNotFound}, expect: true},
+		{name: "unauthorized", err: fmt.Errorf("failed to reveal secret: %w", &tools.StatusError{StatusCode: http.StatusUnauthorized}), expect: true},
+		{name: "server error", err: &tools.StatusError{StatusCode: http.StatusInternalServerError}},
+		{name: "network", err: errors.New("failed to execute request: connection refused")},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := otsNeedsPassphrase(tc.err); got != tc.expect {
+				t.Errorf("expected %v, got %v", tc.expect, got)
+			}
+		})
+	}
+}
+
+func TestOneTimeSecretSourceParse(t *testing.T) {
+	t.Setenv(otsEnvURL, "ots.example.com/")
+	testCases := []struct {
+		arg        string
+		expectBase string
+		expectKey  string
+		expectErr  bool
+	}{
+		{arg: "https://eu.onetimesecret.com/secret/abc", expectBase: "https://eu.onetimesecret.com", expectKey: "abc"},
+		{arg: "https://example.com/ots/secret/abc/", expectBase: "https://example.com/ots", expectKey: "abc"},
+		{arg: "ots:abc", expectBase: "https://ots.example.com", expectKey: "abc"},
+		{arg: "https://eu.onetimesecret.com/private/abc", expectErr: true},
+		{arg: "ots:", expectErr: true},
+		{arg: "./file.env", expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.arg, func(t *testing.T) {
+			src := &OneTimeSecretSource{}
+			base, key, err := src.parse(tc.arg)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected error, got %s %s", base, key)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parse() returned an error: %v", err)
+			}
+			if base != tc.expectBase || key != tc.expectKey {
+				t.Errorf("expected %s %s, got %s %s", tc.expectBase, tc.expectKey, base, key)
+			}
+		})
+	}
+}
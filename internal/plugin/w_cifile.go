@@ -0,0 +1,44 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package plugin
+
+import (
+	"log/slog"
+
+	"github.com/withholm/polyenv/internal/tools"
+)
+
+// writes a file that a ci system picks up, ie gitlab dotenv reports or bitbucket artifacts.
+// the file is overwritten, and only readable by the current user
+type CIFileWriter struct {
+	name string
+	//format used by default
+	format string
+	//path used if Path is not set
+	defaultPath string
+	//path to write to
+	Path string
+}
+
+func (e *CIFileWriter) Name() string {
+	return e.name
+}
+
+func (e *CIFileWriter) AcceptedFormats() (accepted []string, deny []string) {
+	return []string{e.format}, []string{}
+}
+
+func (e *CIFileWriter) Write(data []byte) error {
+	path := e.Path
+	if path == "" {
+		path = e.defaultPath
+	}
+
+	err := tools.WriteFileAtomic(path, data, 0o600)
+	if err != nil {
+		return err
+	}
+	slog.Info("wrote file", "writer", e.name, "path", path)
+	return nil
+}
@@ -0,0 +1,172 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"slices"
+
+	"github.com/withholm/polyenv/internal/model"
+	"github.com/withholm/polyenv/internal/tools"
+)
+
+type FileWriteMode string
+
+const (
+	FileOverwrite FileWriteMode = "overwrite"
+	FileMerge     FileWriteMode = "merge"
+	FileAppend    FileWriteMode = "append"
+)
+
+var FileWriteModes = []FileWriteMode{FileOverwrite, FileMerge, FileAppend}
+
+// formats that can be merged. they need both an input and output formatter
+var fileMergeFormats = []string{"dotenv", "json", "jsonArr"}
+
+// formats that can be appended. only line based formats stay valid when concatenated
+var fileAppendFormats = []string{"dotenv", "posix", "properties", "systemd", "docker-env"}
+
+// writes to a file through a temp file and rename, so the file is never half written.
+// the file gets 0600 if anything written is a secret
+type FileWriter struct {
+	Path string
+	Mode FileWriteMode
+	//selected output format, used to read the existing file on merge
+	Format string
+	env    []model.StoredEnv
+}
+
+func (e *FileWriter) Name() string {
+	return "file"
+}
+
+func (e *FileWriter) AcceptedFormats() (accepted []string, deny []string) {
+	return []string{"dotenv", "*"}, []string{"stats", "inspect", "pick"}
+}
+
+func (e *FileWriter) SetEnv(env []model.StoredEnv) {
+	e.env = env
+}
+
+func (e *FileWriter) Write(data []byte) error {
+	if e.Path == "" {
+		return fmt.Errorf("no path set. use --path to select a file")
+	}
+	mode := e.Mode
+	if mode == "" {
+		mode = FileOverwrite
+	}
+
+	secret := envHasSecret(e.env)
+	perm := fs.FileMode(0o644)
+	existing, err := os.ReadFile(e.Path)
+	exists := err == nil
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read %s: %w", e.Path, err)
+	}
+	if exists {
+		info, err := os.Stat(e.Path)
+		if err != nil {
+			return fmt.Errorf("failed to stat %s: %w", e.Path, err)
+		}
+		perm = info.Mode().Perm()
+	}
+
+	switch mode {
+	case FileOverwrite:
+	case FileAppend:
+		if !slices.Contains(fileAppendFormats, e.Format) {
+			return fmt.Errorf("append is not supported for format '%s'. supported formats: %v", e.Format, fileAppendFormats)
+		}
+		if len(existing) > 0 && existing[len(existing)-1] != '\n' {
+			existing = append(existing, '\n')
+		}
+		data = append(existing, data...)
+	case FileMerge:
+		if exists {
+			var merged []model.StoredEnv
+			data, merged, err = e.merge(existing)
+			if err != nil {
+				return err
+			}
+			secret = envHasSecret(merged)
+		}
+	default:
+		return fmt.Errorf("unknown write mode '%s'. available modes: %v", mode, FileWriteModes)
+	}
+
+	if secret {
+		perm = 0o600
+	}
+
+	err = tools.WriteFileAtomic(e.Path, data, perm)
+	if err != nil {
+		return err
+	}
+	slog.Info("wrote file", "path", e.Path, "mode", mode, "perm", perm)
+	return nil
+}
+
+// merges the values with the existing file. values being exported wins over existing ones
+func (e *FileWriter) merge(existing []byte) ([]byte, []model.StoredEnv, error) {
+	if !slices.Contains(fileMergeFormats, e.Format) {
+		return nil, nil, fmt.Errorf("merge is not supported for format '%s'. supported formats: %v", e.Format, fileMergeFormats)
+	}
+	in, ok := tools.InequalFindInMap(InputFormatters, e.Format)
+	if !ok {
+		return nil, nil, fmt.Errorf("no input formatter for '%s'", e.Format)
+	}
+	out, ok := tools.InequalFindInMap(OutputFormatters, e.Format)
+	if !ok {
+		return nil, nil, fmt.Errorf("no output formatter for '%s'", e.Format)
+	}
+
+	var current []model.StoredEnv
+	if len(existing) > 0 {
+		input, err := in().InputFormat(existing)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s as %s: %w", e.Path, e.Format, err)
+		}
+		current, err = InputToEnv(input)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s as %s: %w", e.Path, e.Format, err)
+		}
+	}
+
+	index := make(map[string]int, len(current))
+	for i, v := range current {
+		index[v.Key] = i
+	}
+	for _, v := range e.env {
+		if i, ok := index[v.Key]; ok {
+			current[i] = v
+			continue
+		}
+		index[v.Key] = len(current)
+		current = append(current, v)
+	}
+
+	data, err := out().OutputFormat(current)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to format merged values: %w", err)
+	}
+	return data, current, nil
+}
+
+// true if any of the values is a secret or detected as one
+func envHasSecret(env []model.StoredEnv) bool {
+	for _, v := range env {
+		if v.IsSecret {
+			return true
+		}
+		if s, _ := v.DetectSecret(); s {
+			return true
+		}
+	}
+	return false
+}
@@ -5,8 +5,13 @@ package plugin
 
 import (
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"strings"
+
+	"github.com/withholm/polyenv/internal/model"
+	"github.com/withholm/polyenv/internal/tools"
 )
 
 type GithubWriterType string
@@ -18,6 +23,11 @@ const (
 
 type GithubWriter struct {
 	typ GithubWriterType
+	env []model.StoredEnv
+	//write a table of keys (no values) to $GITHUB_STEP_SUMMARY
+	Summary bool
+	//defaults to os.Stdout
+	out io.Writer
 }
 
 func (e *GithubWriter) Name() string {
@@ -25,7 +35,11 @@ func (e *GithubWriter) Name() string {
 }
 
 func (e *GithubWriter) AcceptedFormats() (accepted []string, deny []string) {
-	return []string{"dotenv"}, []string{}
+	return []string{"github"}, []string{}
+}
+
+func (e *GithubWriter) SetEnv(env []model.StoredEnv) {
+	e.env = env
 }
 
 func (e *GithubWriter) Write(data []byte) error {
@@ -43,6 +57,17 @@ func (e *GithubWriter) Write(data []byte) error {
 		slog.Error("no env set. are you running this in a github action?", "env", outputEnv)
 		os.Exit(1)
 	}
+
+	out := e.out
+	if out == nil {
+		out = os.Stdout
+	}
+	// mask before the values are available to later steps
+	err := writeGithubMasks(out, e.env)
+	if err != nil {
+		return fmt.Errorf("failed to mask secrets: %w", err)
+	}
+
 	f, err := os.OpenFile(envFile, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
 	if err != nil {
 		slog.Error("failed to open github file", "env", outputEnv, "error", err)
@@ -62,6 +87,100 @@ func (e *GithubWriter) Write(data []byte) error {
 		os.Exit(1)
 	}
 
-	fmt.Println("Wrote environment variable to", outputEnv)
+	if e.Summary {
+		err = e.writeSummary()
+		if err != nil {
+			return fmt.Errorf("failed to write step summary: %w", err)
+		}
+	}
+
+	_, err = fmt.Fprintln(out, "Wrote environment variable to", outputEnv)
+	return err
+}
+
+// appends a markdown table of the exported keys to $GITHUB_STEP_SUMMARY. values are never written
+func (e *GithubWriter) writeSummary() error {
+	summaryFile := os.Getenv("GITHUB_STEP_SUMMARY")
+	if summaryFile == "" {
+		slog.Warn("GITHUB_STEP_SUMMARY is not set, skipping summary")
+		return nil
+	}
+
+	// paths are shown from the repo root, the runner path is just noise
+	root, err := tools.GetGitRootOrCwd()
+	if err != nil {
+		return fmt.Errorf("failed to get project root: %w", err)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("### polyenv\n\n")
+	sb.WriteString("| Key | Secret | File |\n|---|---|---|\n")
+	for _, v := range e.env {
+		secret := v.IsSecret
+		if !secret {
+			secret, _ = v.DetectSecret()
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %v | %s |\n", markdownCode(v.Key), secret, markdownText(relativePath(root, v.File))))
+	}
+	sb.WriteString("\n")
+
+	f, err := os.OpenFile(summaryFile, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			slog.Error("failed to close github summary file", "error", err)
+		}
+	}()
+	_, err = f.WriteString(sb.String())
+	return err
+}
+
+// wraps s in a code span for a table cell. the fence is longer than any backtick run in s,
+// and '|' is escaped, as github splits cells on it even in code spans
+func markdownCode(s string) string {
+	run, longest := 0, 0
+	for _, r := range s {
+		if r != '`' {
+			run = 0
+			continue
+		}
+		run++
+		longest = max(longest, run)
+	}
+	fence := strings.Repeat("`", longest+1)
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+	return fence + strings.ReplaceAll(s, "|", `\|`) + fence
+}
+
+// escapes text for a table cell
+func markdownText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "|", `\|`, "`", "\\`").Replace(s)
+}
+
+// writes '::add-mask::' for every secret (IsSecret or detected).
+// github masks line by line, so multiline values are masked per line
+func writeGithubMasks(out io.Writer, env []model.StoredEnv) error {
+	for _, v := range env {
+		secret := v.IsSecret
+		if !secret {
+			secret, _ = v.DetectSecret()
+		}
+		if !secret || v.Value == "" {
+			continue
+		}
+		for _, line := range strings.Split(strings.ReplaceAll(v.Value, "\r\n", "\n"), "\n") {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			_, err := fmt.Fprintf(out, "::add-mask::%s\n", line)
+			if err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
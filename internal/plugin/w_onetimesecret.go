@@ -7,7 +7,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
+	"net/mail"
+	"os"
+	"slices"
 	"strings"
 	"time"
 
@@ -17,8 +21,42 @@ import (
 	"github.com/withholm/polyenv/internal/tui"
 )
 
+const (
+	// region of onetimesecret.com to use. ignored if url is set
+	otsEnvRegion = "POLYENV_OTS_REGION"
+	// credentials for authenticated accounts. never stored or given as flags
+	otsEnvUsername = "POLYENV_OTS_USERNAME"
+	otsEnvAPIKey   = "POLYENV_OTS_API_KEY"
+)
+
+// regions hosted by onetimesecret.com
+var OtsRegions = []string{"eu", "us", "ca", "nz"}
+
 type OtsWriter struct {
+	//options set by user. empty values are read from env or asked for
+	Options OtsOptions
+
 	concealOpts *otsOptions
+	//defaults to os.Stdout
+	out io.Writer
+}
+
+// user defined options for the ots writer
+type OtsOptions struct {
+	//region of onetimesecret.com (eu, us, ca, nz). defaults to POLYENV_OTS_REGION or eu
+	Region string
+	//base url of a self hosted instance. defaults to POLYENV_OTS_URL
+	URL string
+	//email to send the link to. requires an authenticated account
+	Recipient string
+	//passphrase needed to reveal the secret
+	Passphrase string
+	//days or iso duration. max P7D
+	TTL string
+	//'text' or 'json'
+	Output string
+	//ask for missing options with a form
+	Interactive bool
 }
 
 func (e *OtsWriter) Name() string {
@@ -27,6 +65,8 @@ func (e *OtsWriter) Name() string {
 
 type otsOptions struct {
 	URL           string
+	Username      string
+	APIKey        string
 	ConcealMaxTTL *duration.Duration
 }
 
@@ -53,6 +93,15 @@ type otsConcealResponse struct {
 	} `json:"record"`
 }
 
+// structured result of a concealed secret
+type OtsResult struct {
+	URL         string    `json:"url"`
+	MetadataURL string    `json:"metadata_url"`
+	Expires     time.Time `json:"expires"`
+	Passphrase  bool      `json:"passphrase"`
+	Recipient   string    `json:"recipient,omitempty"`
+}
+
 func (r *otsConcealResponse) Expires() (time.Time, error) {
 	duration := time.Duration(r.Record.Metadata.SecretTTL) * time.Second
 	return time.Now().Add(duration), nil
@@ -87,15 +136,67 @@ func (e *OtsWriter) GetTTL(s string) (*duration.Duration, error) {
 	return duration, nil
 }
 
+// validates ttl and max duration
+func (e *OtsWriter) validateTTL(s string) error {
+	dur, err := e.GetTTL(s)
+	if err != nil {
+		return err
+	}
+	if dur.ToTimeDuration() > e.concealOpts.ConcealMaxTTL.ToTimeDuration() {
+		return fmt.Errorf("max duration is %s", e.concealOpts.ConcealMaxTTL.String())
+	}
+	return nil
+}
+
 func (e *OtsWriter) GetOptions() (*otsOptions, error) {
-	d, err := e.GetTTL("P7D")
+	d, err := duration.Parse("P7D")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get TTL: %w", err)
 	}
-	return &otsOptions{
-		URL:           "eu.onetimesecret.com",
+	url, err := e.baseURL()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &otsOptions{
+		URL:           url,
+		Username:      os.Getenv(otsEnvUsername),
+		APIKey:        os.Getenv(otsEnvAPIKey),
 		ConcealMaxTTL: d,
-	}, nil
+	}
+	if (opts.Username == "") != (opts.APIKey == "") {
+		return nil, fmt.Errorf("both %s and %s must be set to use an authenticated account", otsEnvUsername, otsEnvAPIKey)
+	}
+	return opts, nil
+}
+
+// resolves base url from options
+func (e *OtsWriter) baseURL() (string, error) {
+	return otsResolveURL(e.Options.URL, e.Options.Region)
+}
+
+// resolves base url. flags wins over env, and url wins over region:
+// url, region, POLYENV_OTS_URL, POLYENV_OTS_REGION, then eu.onetimesecret.com
+func otsResolveURL(url, region string) (string, error) {
+	if url == "" && region == "" {
+		url = os.Getenv(otsEnvURL)
+		region = os.Getenv(otsEnvRegion)
+	}
+	if url != "" {
+		if !strings.Contains(url, "://") {
+			url = "https://" + url
+		}
+		return strings.TrimSuffix(url, "/"), nil
+	}
+
+	if region == "" {
+		return otsDefaultURL, nil
+	}
+	region = strings.ToLower(region)
+	if !slices.Contains(OtsRegions, region) {
+		return "", fmt.Errorf("unknown region '%s'. supported regions: %v", region, OtsRegions)
+	}
+	return fmt.Sprintf("https://%s.onetimesecret.com", region), nil
 }
 
 // ses options by https://docs.onetimesecret.com/en/rest-api/ to deliver form
@@ -106,23 +207,28 @@ func (e *OtsWriter) Write(data []byte) error {
 		return fmt.Errorf("failed to get options: %w", err)
 	}
 
+	output := strings.ToLower(e.Options.Output)
+	if output == "" {
+		output = "text"
+	}
+	if output != "text" && output != "json" {
+		return fmt.Errorf("unknown output '%s'. expected text or json", e.Options.Output)
+	}
+
 	secret := otsConcealSecret{
-		Secret:    string(data),
-		TTL:       e.concealOpts.ConcealMaxTTL.ToTimeDuration().Seconds(),
-		PassPrase: "",
-	}
-	var ttl string
-	form := huh.NewForm(
-		huh.NewGroup(
-			// huh.NewInput[string]().
-			// 	Title("Recipient email").
-			// 	Description("The email address of the recipient (enter to leave empty)").
-			// 	Required(true).
-			// 	Value(&secret.RecipientEmail),
+		Secret:         string(data),
+		PassPrase:      e.Options.Passphrase,
+		RecipientEmail: e.Options.Recipient,
+	}
+	ttl := e.Options.TTL
+
+	if e.Options.Interactive {
+		fields := []huh.Field{
 			huh.NewInput().
 				Title("Passphrase").
 				Description("The passphrase to use for encryption").
 				Placeholder("Enter to leave empty").
+				EchoMode(huh.EchoModePassword).
 				Value(&secret.PassPrase),
 			huh.NewInput().
 				CharLimit(4).
@@ -130,55 +236,110 @@ func (e *OtsWriter) Write(data []byte) error {
 				Description("How long the secret should be valid for? either number of days or iso duration (max P7D)").
 				Placeholder(e.concealOpts.ConcealMaxTTL.String()).
 				Value(&ttl).
-				Validate(func(s string) error {
-					dur, err := e.GetTTL(s)
-					if err != nil {
-						return err
-					}
-					if dur.Days > 7 {
-						return fmt.Errorf("max duration is 7 days (P7D)")
-					}
-					return nil
-				}),
-		),
-	)
-	tui.RunHuh(form)
-
-	var usingTTL *duration.Duration
-	usingTTL, err = e.GetTTL(ttl)
+				Validate(e.validateTTL),
+		}
+		// recipient only works for authenticated accounts
+		if e.concealOpts.Username != "" {
+			fields = append([]huh.Field{
+				huh.NewInput().
+					Title("Recipient email").
+					Description("The email address of the recipient (enter to leave empty)").
+					Value(&secret.RecipientEmail).
+					Validate(validateOtsRecipient),
+			}, fields...)
+		}
+		tui.RunHuh(huh.NewForm(huh.NewGroup(fields...)))
+	}
+
+	err = e.validateTTL(ttl)
+	if err != nil {
+		return fmt.Errorf("invalid ttl: %w", err)
+	}
+	usingTTL, err := e.GetTTL(ttl)
 	if err != nil {
 		return fmt.Errorf("failed to get TTL: %w", err)
 	}
-
 	secret.TTL = float64(usingTTL.ToTimeDuration().Seconds())
 
+	if secret.RecipientEmail != "" {
+		if e.concealOpts.Username == "" {
+			return fmt.Errorf("recipient requires an authenticated account. set %s and %s", otsEnvUsername, otsEnvAPIKey)
+		}
+		err = validateOtsRecipient(secret.RecipientEmail)
+		if err != nil {
+			return err
+		}
+	}
+
 	body := map[string]any{
 		"secret": secret,
 	}
 	resp := otsConcealResponse{}
 
 	httpClient := tools.NewPolyenvHTTPClient()
-	err = httpClient.Post(context.Background(), "https://eu.onetimesecret.com/api/v2/secret/conceal", body, &resp)
+	if e.concealOpts.Username != "" {
+		httpClient = httpClient.WithBasicAuth(e.concealOpts.Username, e.concealOpts.APIKey)
+	}
+	err = httpClient.Post(context.Background(), e.concealOpts.URL+"/api/v2/secret/conceal", body, &resp)
 	if err != nil {
 		return fmt.Errorf("failed to post to OneTimeSecret: %w", err)
 	}
+	if resp.Record.Secret.Identifier == "" {
+		return fmt.Errorf("OneTimeSecret did not return a secret identifier")
+	}
 	respjson, err := json.MarshalIndent(resp, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal response: %w", err)
 	}
 
 	slog.Debug("response", "json", string(respjson))
-	slog.Info("response", "success", resp.Success, "Shrimp", resp.Shrimp)
 	exp, err := resp.Expires()
 	if err != nil {
 		return err
 	}
-	out := []string{
+
+	result := OtsResult{
+		URL:         fmt.Sprintf("%s/secret/%s", e.concealOpts.URL, resp.Record.Secret.Identifier),
+		MetadataURL: fmt.Sprintf("%s/private/%s", e.concealOpts.URL, resp.Record.Metadata.Identifier),
+		Expires:     exp.UTC().Truncate(time.Second),
+		Passphrase:  secret.PassPrase != "",
+		Recipient:   secret.RecipientEmail,
+	}
+	return e.writeResult(result, output)
+}
+
+func (e *OtsWriter) writeResult(result OtsResult, output string) error {
+	out := e.out
+	if out == nil {
+		out = os.Stdout
+	}
+
+	if output == "json" {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+
+	lines := []string{
 		"generated via 'ots' plugin for polyenv (github.com/withholm/polyenv)",
-		fmt.Sprintf("url: https://eu.onetimesecret.com/secret/%s", resp.Record.Secret.Identifier),
-		fmt.Sprintf("it will expire in %s", exp.Format("2006-01-02 15:04:05Z")),
-		"if you already have polyenv, you can run 'polyenv !{yourenv} import {link or slug}' to import the secret to your env",
+		fmt.Sprintf("url: %s", result.URL),
+		fmt.Sprintf("it will expire in %s", result.Expires.Format("2006-01-02 15:04:05Z")),
+		"if you already have polyenv, you can run 'polyenv !{yourenv} import {url}' to import the secret to your env",
+	}
+	if result.Recipient != "" {
+		lines = append(lines, fmt.Sprintf("the link was sent to %s", result.Recipient))
+	}
+	_, err := fmt.Fprintln(out, strings.Join(lines, "\n"))
+	return err
+}
+
+func validateOtsRecipient(s string) error {
+	if s == "" {
+		return nil
+	}
+	_, err := mail.ParseAddress(s)
+	if err != nil {
+		return fmt.Errorf("invalid email '%s'", s)
 	}
-	fmt.Println(strings.Join(out, "\n"))
 	return nil
 }
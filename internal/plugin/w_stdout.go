@@ -22,5 +22,5 @@ func (e *StdOutWriter) Write(data []byte) error {
 }
 
 func (e *StdOutWriter) AcceptedFormats() (accepted []string, deny []string) {
-	return []string{"stats", "*"}, []string{}
+	return []string{"inspect", "*"}, []string{}
 }
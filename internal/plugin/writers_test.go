@@ -0,0 +1,412 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/withholm/polyenv/internal/model"
+	"github.com/withholm/polyenv/internal/tools"
+)
+
+func TestOtsWriter(t *testing.T) {
+	t.Setenv(otsEnvURL, "")
+	t.Setenv(otsEnvRegion, "")
+	var got struct {
+		Secret otsConcealSecret `json:"secret"`
+	}
+	var gotUser string
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v2/secret/conceal", func(w http.ResponseWriter, r *http.Request) {
+		gotUser, _, _ = r.BasicAuth()
+		err := json.NewDecoder(r.Body).Decode(&got)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		resp := otsConcealResponse{Success: true}
+		resp.Record.Secret.Identifier = "secretid"
+		resp.Record.Metadata.Identifier = "metaid"
+		resp.Record.Metadata.SecretTTL = got.Secret.TTL
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	t.Setenv(otsEnvUsername, "me@example.com")
+	t.Setenv(otsEnvAPIKey, "key")
+
+	out := &bytes.Buffer{}
+	writer := &OtsWriter{
+		out: out,
+		Options: OtsOptions{
+			URL:        srv.URL + "/",
+			Passphrase: "hunter2",
+			TTL:        "1",
+			Recipient:  "you@example.com",
+			Output:     "json",
+		},
+	}
+	err := writer.Write([]byte("A=1"))
+	if err != nil {
+		t.Fatalf("Write() returned an error: %v", err)
+	}
+
+	if got.Secret.Secret != "A=1" || got.Secret.PassPrase != "hunter2" || got.Secret.RecipientEmail != "you@example.com" {
+		t.Errorf("unexpected request: %+v", got.Secret)
+	}
+	if got.Secret.TTL != (24 * time.Hour).Seconds() {
+		t.Errorf("expected ttl of 1 day, got %f", got.Secret.TTL)
+	}
+	if gotUser != "me@example.com" {
+		t.Errorf("expected basic auth user, got '%s'", gotUser)
+	}
+
+	var result OtsResult
+	err = json.Unmarshal(out.Bytes(), &result)
+	if err != nil {
+		t.Fatalf("failed to parse output: %v\n%s", err, out.String())
+	}
+	if result.URL != srv.URL+"/secret/secretid" {
+		t.Errorf("unexpected url %s", result.URL)
+	}
+	if !result.Passphrase {
+		t.Errorf("expected passphrase to be reported")
+	}
+	if time.Until(result.Expires) > 25*time.Hour || time.Until(result.Expires) < 23*time.Hour {
+		t.Errorf("unexpected expiry %s", result.Expires)
+	}
+
+	//errors before anything is sent
+	testCases := []struct {
+		name    string
+		options OtsOptions
+		noAuth  bool
+	}{
+		{name: "ttl too long", options: OtsOptions{URL: srv.URL, TTL: "P8D"}},
+		{name: "unknown region", options: OtsOptions{Region: "mars"}},
+		{name: "unknown output", options: OtsOptions{URL: srv.URL, Output: "xml"}},
+		{name: "invalid recipient", options: OtsOptions{URL: srv.URL, Recipient: "not an email"}},
+		{name: "recipient without account", options: OtsOptions{URL: srv.URL, Recipient: "you@example.com"}, noAuth: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.noAuth {
+				t.Setenv(otsEnvUsername, "")
+				t.Setenv(otsEnvAPIKey, "")
+			}
+			got.Secret = otsConcealSecret{}
+			writer := &OtsWriter{out: &bytes.Buffer{}, Options: tc.options}
+			err := writer.Write([]byte("A=1"))
+			if err == nil {
+				t.Fatalf("expected error")
+			}
+			if got.Secret.Secret != "" {
+				t.Errorf("expected nothing to be sent")
+			}
+		})
+	}
+}
+
+func TestOtsWriterBaseURL(t *testing.T) {
+	t.Setenv(otsEnvURL, "")
+	t.Setenv(otsEnvRegion, "")
+	testCases := []struct {
+		options OtsOptions
+		expect  string
+	}{
+		{options: OtsOptions{}, expect: "https://eu.onetimesecret.com"},
+		{options: OtsOptions{Region: "US"}, expect: "https://us.onetimesecret.com"},
+		{options: OtsOptions{Region: "us", URL: "ots.example.com/"}, expect: "https://ots.example.com"},
+	}
+	for _, tc := range testCases {
+		writer := &OtsWriter{Options: tc.options}
+		got, err := writer.baseURL()
+		if err != nil {
+			t.Fatalf("baseURL() returned an error: %v", err)
+		}
+		if !strings.EqualFold(got, tc.expect) {
+			t.Errorf("expected %s, got %s", tc.expect, got)
+		}
+	}
+}
+
+// flags wins over env, url wins over region
+func TestOtsResolveURLPrecedence(t *testing.T) {
+	testCases := []struct {
+		name      string
+		url       string
+		region    string
+		envURL    string
+		envRegion string
+		expect    string
+	}{
+		{name: "flag url", url: "flag.example.com", region: "us", envURL: "env.example.com", envRegion: "ca", expect: "https://flag.example.com"},
+		{name: "flag region over env url", region: "us", envURL: "env.example.com", envRegion: "ca", expect: "https://us.onetimesecret.com"},
+		{name: "env url", envURL: "env.example.com", envRegion: "ca", expect: "https://env.example.com"},
+		{name: "env region", envRegion: "ca", expect: "https://ca.onetimesecret.com"},
+		{name: "default", expect: "https://eu.onetimesecret.com"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(otsEnvURL, tc.envURL)
+			t.Setenv(otsEnvRegion, tc.envRegion)
+			got, err := otsResolveURL(tc.url, tc.region)
+			if err != nil {
+				t.Fatalf("otsResolveURL() returned an error: %v", err)
+			}
+			if got != tc.expect {
+				t.Errorf("expected %s, got %s", tc.expect, got)
+			}
+		})
+	}
+}
+
+func TestGithubWriterMasks(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "github_env")
+	t.Setenv("GITHUB_ENV", file)
+
+	var out bytes.Buffer
+	w := &GithubWriter{typ: GithubToEnv, out: &out}
+	w.SetEnv([]model.StoredEnv{
+		{Key: "PLAIN", Value: "hello"},
+		{Key: "VAULT", Value: "line1\nline2", IsSecret: true},
+		{Key: "DB_PASSWORD", Value: "detected"},
+	})
+	err := w.Write([]byte("PLAIN=hello"))
+	if err != nil {
+		t.Fatalf("Write() returned an error: %v", err)
+	}
+
+	masks := "::add-mask::line1\n::add-mask::line2\n::add-mask::detected\n"
+	if !strings.HasPrefix(out.String(), masks) {
+		t.Errorf("expected masks before anything else, got %q", out.String())
+	}
+	if strings.Contains(out.String(), "hello") {
+		t.Errorf("non secret value was masked: %q", out.String())
+	}
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("failed to read github env file: %v", err)
+	}
+	if string(content) != "PLAIN=hello\n" {
+		t.Errorf("unexpected github env content %q", string(content))
+	}
+}
+
+func TestGithubWriterFormats(t *testing.T) {
+	w := &GithubWriter{typ: GithubToEnv}
+	if !AcceptsFormat(w, "github") {
+		t.Error("expected github-env to accept github")
+	}
+	if AcceptsFormat(w, "dotenv") {
+		t.Error("expected github-env to refuse dotenv")
+	}
+}
+
+func TestGithubWriterSummary(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("GITHUB_OUTPUT", filepath.Join(dir, "out"))
+	summary := filepath.Join(dir, "summary")
+	t.Setenv("GITHUB_STEP_SUMMARY", summary)
+
+	root, err := tools.GetGitRootOrCwd()
+	if err != nil {
+		t.Fatalf("failed to get project root: %v", err)
+	}
+
+	w := &GithubWriter{typ: GithubToOutput, out: &bytes.Buffer{}, Summary: true}
+	w.SetEnv([]model.StoredEnv{
+		{Key: "PLAIN", Value: "hello", File: ".env"},
+		{Key: "VAULT", Value: "s3cr3tvalue", IsSecret: true, File: ".env.secret"},
+		{Key: "A|`B", Value: "x", File: filepath.Join(root, "sub", ".env.a|`b")},
+	})
+	err = w.Write([]byte("PLAIN=hello\nVAULT=s3cr3tvalue"))
+	if err != nil {
+		t.Fatalf("Write() returned an error: %v", err)
+	}
+	content, err := os.ReadFile(summary)
+	if err != nil {
+		t.Fatalf("failed to read summary: %v", err)
+	}
+	if strings.Contains(string(content), "hello") || strings.Contains(string(content), "s3cr3tvalue") {
+		t.Errorf("summary contains values: %q", string(content))
+	}
+	if !strings.Contains(string(content), "| `VAULT` | true | .env.secret |") {
+		t.Errorf("summary missing secret row: %q", string(content))
+	}
+	// cells are escaped, and paths relative to the repo root
+	row := "| ``A\\|`B`` | false | " + filepath.Join("sub", ".env.a\\|\\`b") + " |"
+	if !strings.Contains(string(content), row) {
+		t.Errorf("summary missing escaped row %q: %q", row, string(content))
+	}
+}
+
+func TestCIFileWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "build.env")
+	w := &CIFileWriter{name: "gitlab-dotenv", format: "gitlab", defaultPath: "polyenv.env", Path: path}
+	err := w.Write([]byte("A=1\n"))
+	if err != nil {
+		t.Fatalf("Write() returned an error: %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+	if string(content) != "A=1\n" {
+		t.Errorf("unexpected content %q", string(content))
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("failed to stat file: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("expected mode 0600, got %v", info.Mode().Perm())
+	}
+}
+
+func TestFileWriter(t *testing.T) {
+	testCases := []struct {
+		name       string
+		mode       FileWriteMode
+		format     string
+		existing   string
+		env        []model.StoredEnv
+		data       string
+		expected   string
+		expectPerm os.FileMode
+		expectErr  bool
+	}{
+		{
+			name:       "overwrite",
+			mode:       FileOverwrite,
+			format:     "dotenv",
+			existing:   "OLD=1\n",
+			env:        []model.StoredEnv{{Key: "A", Value: "1"}},
+			data:       "A=\"1\"\n",
+			expected:   "A=\"1\"\n",
+			expectPerm: 0o644,
+		},
+		{
+			name:       "new file with secret",
+			mode:       FileOverwrite,
+			format:     "dotenv",
+			env:        []model.StoredEnv{{Key: "A", Value: "1", IsSecret: true}},
+			data:       "A=\"1\"\n",
+			expected:   "A=\"1\"\n",
+			expectPerm: 0o600,
+		},
+		{
+			name:       "append",
+			mode:       FileAppend,
+			format:     "posix",
+			existing:   "export OLD='1'",
+			env:        []model.StoredEnv{{Key: "A", Value: "1"}},
+			data:       "export A='1'\n",
+			expected:   "export OLD='1'\nexport A='1'\n",
+			expectPerm: 0o644,
+		},
+		{
+			name:      "append unsupported format",
+			mode:      FileAppend,
+			format:    "json",
+			existing:  `{"OLD":"1"}`,
+			env:       []model.StoredEnv{{Key: "A", Value: "1"}},
+			data:      `{"A":"1"}`,
+			expectErr: true,
+		},
+		{
+			name:       "merge dotenv",
+			mode:       FileMerge,
+			format:     "dotenv",
+			existing:   "OLD=1\nA=old\n",
+			env:        []model.StoredEnv{{Key: "A", Value: "new"}, {Key: "B", Value: "2"}},
+			expected:   "A=\"new\"\nB=2\nOLD=1\n",
+			expectPerm: 0o644,
+		},
+		{
+			name:       "merge json",
+			mode:       FileMerge,
+			format:     "json",
+			existing:   `{"OLD":"1","A":"old"}`,
+			env:        []model.StoredEnv{{Key: "A", Value: "new"}},
+			expected:   "{\n  \"A\": \"new\",\n  \"OLD\": \"1\"\n}",
+			expectPerm: 0o644,
+		},
+		{
+			name:       "merge existing secret",
+			mode:       FileMerge,
+			format:     "dotenv",
+			existing:   "DB_PASSWORD=hunter2\n",
+			env:        []model.StoredEnv{{Key: "A", Value: "1"}},
+			expected:   "A=1\nDB_PASSWORD=\"hunter2\"\n",
+			expectPerm: 0o600,
+		},
+		{
+			name:      "merge unsupported format",
+			mode:      FileMerge,
+			format:    "posix",
+			existing:  "export OLD='1'\n",
+			env:       []model.StoredEnv{{Key: "A", Value: "1"}},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "out.env")
+			if tc.existing != "" {
+				err := os.WriteFile(path, []byte(tc.existing), 0o644)
+				if err != nil {
+					t.Fatalf("failed to write existing file: %v", err)
+				}
+			}
+
+			w := &FileWriter{Path: path, Mode: tc.mode, Format: tc.format}
+			w.SetEnv(tc.env)
+			err := w.Write([]byte(tc.data))
+			if tc.expectErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Write() returned an error: %v", err)
+			}
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("failed to read file: %v", err)
+			}
+			if string(content) != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, string(content))
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatalf("failed to stat file: %v", err)
+			}
+			if info.Mode().Perm() != tc.expectPerm {
+				t.Errorf("expected perm %v, got %v", tc.expectPerm, info.Mode().Perm())
+			}
+
+			entries, err := os.ReadDir(filepath.Dir(path))
+			if err != nil {
+				t.Fatalf("failed to read dir: %v", err)
+			}
+			if len(entries) != 1 {
+				t.Errorf("expected temp file to be removed, found %d files", len(entries))
+			}
+		})
+	}
+}
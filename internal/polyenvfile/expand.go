@@ -0,0 +1,270 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package polyenvfile
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/withholm/polyenv/internal/model"
+)
+
+// expands references in raw values (see model.ReadDotenv) over all values, not just the file they are in:
+// $VAR (uppercase, like godotenv), ${VAR}, ${VAR:-default} (unset or empty) and ${VAR-default} (unset).
+// $$ is a literal $ in raw values. unterminated or invalid references are kept as written.
+// references use the effective value of a key (values has to be in precedence order).
+// keys that are not in any file are read from the environment, or are empty.
+// a reference to the key itself is read from the environment, so PATH=${PATH}:/bin works.
+// a value that references a secret is marked as secret
+func expandValues(values []model.StoredEnv) ([]model.StoredEnv, error) {
+	return expandValuesWith(values, nil)
+}
+
+// same as expandValues. if pull is set, it is used to get the value of vault references (polyenv://{vault}/{key})
+func expandValuesWith(values []model.StoredEnv, pull func(model.Secret) (string, error)) ([]model.StoredEnv, error) {
+	e := &expander{
+		raw:      make(map[string]model.StoredEnv, len(values)),
+		expanded: make(map[string]expandedValue, len(values)),
+		pull:     pull,
+	}
+	for _, v := range values {
+		e.raw[v.Key] = v
+	}
+
+	out := make([]model.StoredEnv, len(values))
+	for i, v := range values {
+		var ev expandedValue
+		var err error
+		if e.raw[v.Key] == v {
+			ev, _, err = e.resolve(v.Key)
+		} else {
+			// shadowed values are expanded too, but not used by references
+			ev.value, ev.referencesSecret, err = e.expand(v.Value, v.Key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand %s: %w", v.Key, err)
+		}
+		v.Value = ev.value
+		v.IsSecret = v.IsSecret || ev.referencesSecret
+		out[i] = v
+	}
+	return out, nil
+}
+
+// converts raw values to text without expanding references
+func unexpandedValues(values []model.StoredEnv) []model.StoredEnv {
+	out := make([]model.StoredEnv, len(values))
+	for i, v := range values {
+		v.Value = model.UnescapeRaw(v.Value)
+		out[i] = v
+	}
+	return out
+}
+
+type expander struct {
+	//effective raw value of each key
+	raw map[string]model.StoredEnv
+	//already expanded keys
+	expanded map[string]expandedValue
+	//keys being expanded, to find cycles
+	stack []string
+	//pulls vault references. if nil, they are kept as is
+	pull func(model.Secret) (string, error)
+}
+
+type expandedValue struct {
+	value string
+	//value is, or references, a secret
+	secret bool
+	//value references a secret
+	referencesSecret bool
+}
+
+// expands the effective value of key. returns false if key is not in any file
+func (e *expander) resolve(key string) (expandedValue, bool, error) {
+	if v, ok := e.expanded[key]; ok {
+		return v, true, nil
+	}
+	env, ok := e.raw[key]
+	if !ok {
+		return expandedValue{}, false, nil
+	}
+	for i, k := range e.stack {
+		if k == key {
+			cycle := append(append([]string{}, e.stack[i:]...), key)
+			return expandedValue{}, true, fmt.Errorf("reference cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	if e.pull != nil {
+		s, ok, err := model.ParseVaultReference(key, model.UnescapeRaw(env.Value))
+		if err != nil {
+			return expandedValue{}, true, err
+		}
+		if ok {
+			value, err := e.pull(s)
+			if err != nil {
+				return expandedValue{}, true, err
+			}
+			v := expandedValue{value: value, secret: true}
+			e.expanded[key] = v
+			return v, true, nil
+		}
+	}
+
+	e.stack = append(e.stack, key)
+	value, refSecret, err := e.expand(env.Value, key)
+	e.stack = e.stack[:len(e.stack)-1]
+	if err != nil {
+		return expandedValue{}, true, err
+	}
+
+	secret := refSecret || env.IsSecret
+	if !secret {
+		secret, _ = model.StoredEnv{Key: key, Value: value}.DetectSecret()
+	}
+	v := expandedValue{value: value, secret: secret, referencesSecret: refSecret}
+	e.expanded[key] = v
+	return v, true, nil
+}
+
+// expands all references in raw. self is the key the value belongs to
+func (e *expander) expand(raw string, self string) (string, bool, error) {
+	var b strings.Builder
+	secret := false
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != '$' || i+1 == len(raw) {
+			b.WriteByte(raw[i])
+			continue
+		}
+
+		next := raw[i+1]
+		switch {
+		case next == '$':
+			b.WriteByte('$')
+			i++
+		case next == '{':
+			end := closingBrace(raw, i+2)
+			if end == -1 {
+				// godotenv read these as text, so keep doing that
+				slog.Warn("unterminated reference, keeping it as written", "key", self)
+				b.WriteString(model.UnescapeRaw(raw[i:]))
+				return b.String(), secret, nil
+			}
+			ref := raw[i+2 : end]
+			name, op, def := splitReference(ref)
+			if !isReferenceName(name) {
+				slog.Warn("invalid reference, keeping it as written", "key", self, "reference", model.UnescapeRaw(ref))
+				b.WriteString(model.UnescapeRaw(raw[i : end+1]))
+				i = end
+				continue
+			}
+			v, found, err := e.lookup(name, self, op != "")
+			if err != nil {
+				return "", false, err
+			}
+			if op != "" && (!found || (op == ":-" && v.value == "")) {
+				v.value, v.secret, err = e.expand(def, self)
+				if err != nil {
+					return "", false, err
+				}
+			}
+			b.WriteString(v.value)
+			secret = secret || v.secret
+			i = end
+		case next == '_' || isASCIIUpper(next):
+			end := i + 2
+			for end < len(raw) && (raw[end] == '_' || isASCIIUpper(raw[end]) || isASCIIDigit(raw[end])) {
+				end++
+			}
+			v, _, err := e.lookup(raw[i+1:end], self, false)
+			if err != nil {
+				return "", false, err
+			}
+			b.WriteString(v.value)
+			secret = secret || v.secret
+			i = end - 1
+		default:
+			b.WriteByte('$')
+		}
+	}
+	return b.String(), secret, nil
+}
+
+// finds the value of a referenced key in the files, then in the environment
+func (e *expander) lookup(name string, self string, hasDefault bool) (expandedValue, bool, error) {
+	if name != self {
+		v, found, err := e.resolve(name)
+		if found || err != nil {
+			return v, found, err
+		}
+	}
+	if value, ok := os.LookupEnv(name); ok {
+		return expandedValue{value: value}, true, nil
+	}
+	if !hasDefault {
+		slog.Warn("reference to undefined variable, using empty value", "key", self, "reference", name)
+	}
+	return expandedValue{}, false, nil
+}
+
+// returns the index of the } closing a reference starting at start, or -1
+func closingBrace(raw string, start int) int {
+	depth := 0
+	for i := start; i < len(raw); i++ {
+		switch {
+		case raw[i] == '$' && i+1 < len(raw) && raw[i+1] == '$':
+			i++
+		case raw[i] == '$' && i+1 < len(raw) && raw[i+1] == '{':
+			depth++
+			i++
+		case raw[i] == '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+// splits 'VAR:-default' and 'VAR-default' into name, operator and default
+func splitReference(ref string) (name string, op string, def string) {
+	i := strings.IndexByte(ref, '-')
+	if i == -1 {
+		return ref, "", ""
+	}
+	if i > 0 && ref[i-1] == ':' {
+		return ref[:i-1], ":-", ref[i+1:]
+	}
+	return ref[:i], "-", ref[i+1:]
+}
+
+// same characters as dotenv keys
+func isReferenceName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c != '_' && c != '.' && !isASCIILetter(c) && !isASCIIDigit(c) {
+			return false
+		}
+	}
+	return true
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isASCIIUpper(c byte) bool {
+	return c >= 'A' && c <= 'Z'
+}
+
+func isASCIIDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
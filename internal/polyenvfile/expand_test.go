@@ -0,0 +1,184 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package polyenvfile
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/withholm/polyenv/internal/model"
+)
+
+func TestExpandValues(t *testing.T) {
+	t.Setenv("POLYENV_TEST_FROM_ENV", "env")
+	t.Setenv("PATH", "/usr/bin")
+
+	testCases := []struct {
+		name      string
+		values    []model.StoredEnv
+		expected  []string
+		expectErr string
+	}{
+		{
+			name: "across values",
+			values: []model.StoredEnv{
+				{Key: "HOST", Value: "db"},
+				{Key: "URL", Value: "postgres://$USERNAME@${HOST}:${PORT:-5432}/app"},
+				{Key: "USERNAME", Value: "${POLYENV_TEST_FROM_ENV}"},
+			},
+			expected: []string{"HOST=db", "URL=postgres://env@db:5432/app", "USERNAME=env"},
+		},
+		{
+			name: "defaults",
+			values: []model.StoredEnv{
+				{Key: "EMPTY", Value: ""},
+				{Key: "A", Value: "${EMPTY:-a}|${EMPTY-b}|${MISSING-c}|${MISSING:-${EMPTY:-nested}}"},
+			},
+			expected: []string{"EMPTY=", "A=a||c|nested"},
+		},
+		{
+			name: "effective value is used",
+			values: []model.StoredEnv{
+				{Key: "A", Value: "shadowed", File: "/.env.dev"},
+				{Key: "A", Value: "wins", File: "/sub/.env.dev"},
+				{Key: "B", Value: "${A}"},
+			},
+			expected: []string{"A=shadowed", "A=wins", "B=wins"},
+		},
+		{
+			name: "literal dollar",
+			values: []model.StoredEnv{
+				{Key: "A", Value: "$$HOME $ 5$"},
+			},
+			expected: []string{"A=$HOME $ 5$"},
+		},
+		{
+			name: "self reference reads environment",
+			values: []model.StoredEnv{
+				{Key: "PATH", Value: "${PATH}:/opt/bin"},
+			},
+			expected: []string{"PATH=/usr/bin:/opt/bin"},
+		},
+		{
+			name: "cycle",
+			values: []model.StoredEnv{
+				{Key: "A", Value: "${B}"},
+				{Key: "B", Value: "${C}"},
+				{Key: "C", Value: "$A"},
+			},
+			expectErr: "A -> B -> C -> A",
+		},
+		{
+			name:     "unterminated is kept",
+			values:   []model.StoredEnv{{Key: "A", Value: "tmpl ${foo $$x"}},
+			expected: []string{"A=tmpl ${foo $x"},
+		},
+		{
+			name:     "invalid name is kept",
+			values:   []model.StoredEnv{{Key: "A", Value: "${B C} ${D}"}, {Key: "D", Value: "d"}},
+			expected: []string{"A=${B C} d", "D=d"},
+		},
+		{
+			name:     "lowercase without braces is text",
+			values:   []model.StoredEnv{{Key: "A", Value: "pa$word"}},
+			expected: []string{"A=pa$word"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := expandValues(tc.values)
+			if tc.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expandValues() returned an error: %v", err)
+			}
+			if got := keyValues(out); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestExpandValuesMarksSecrets(t *testing.T) {
+	out, err := expandValues([]model.StoredEnv{
+		{Key: "DB_PASSWORD", Value: "hunter2"},
+		{Key: "DATABASE_URL", Value: "postgres://app:${PASS}@db"},
+		{Key: "HOST", Value: "db"},
+		{Key: "PASS", Value: "${DB_PASSWORD}"},
+		{Key: "VAULTED", Value: "x", IsSecret: true},
+		{Key: "WITH_VAULTED", Value: "${VAULTED}"},
+	})
+	if err != nil {
+		t.Fatalf("expandValues() returned an error: %v", err)
+	}
+	secrets := map[string]bool{}
+	for _, v := range out {
+		secrets[v.Key] = v.IsSecret
+	}
+	expected := map[string]bool{
+		"DB_PASSWORD":  false,
+		"DATABASE_URL": true,
+		"HOST":         false,
+		"PASS":         true,
+		"VAULTED":      true,
+		"WITH_VAULTED": true,
+	}
+	if !reflect.DeepEqual(secrets, expected) {
+		t.Errorf("expected %v, got %v", expected, secrets)
+	}
+}
+
+func TestFile_AllDotenvValuesExpands(t *testing.T) {
+	tmpDir := t.TempDir()
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get current working directory: %v", err)
+	}
+	defer func() {
+		if err := os.Chdir(originalWd); err != nil {
+			t.Fatalf("failed to restore working directory: %v", err)
+		}
+	}()
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatalf("failed to change directory: %v", err)
+	}
+
+	content := "DATABASE_URL=\"postgres://app:${DB_PASSWORD}@db\"\nPRICE='$5'\nPLAIN=pa$$word\nTMPL=\"tmpl ${foo\"\n"
+	err = os.WriteFile(filepath.Join(tmpDir, ".env.dev"), []byte(content), 0o644)
+	if err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	err = os.WriteFile(filepath.Join(tmpDir, ".env.secret.dev"), []byte("DB_PASSWORD=\"p\\$ss\"\n"), 0o600)
+	if err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	file := File{Name: "dev"}
+	values, err := file.AllDotenvValues()
+	if err != nil {
+		t.Fatalf("AllDotenvValues() returned an error: %v", err)
+	}
+	// values that godotenv read as text stay the same
+	expected := []string{"DATABASE_URL=postgres://app:p$ss@db", "DB_PASSWORD=p$ss", "PLAIN=pa$$word", "PRICE=$5", "TMPL=tmpl ${foo"}
+	if got := keyValues(values); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	values, err = file.AllDotenvValuesUnexpanded()
+	if err != nil {
+		t.Fatalf("AllDotenvValuesUnexpanded() returned an error: %v", err)
+	}
+	expected = []string{"DATABASE_URL=postgres://app:${DB_PASSWORD}@db", "DB_PASSWORD=p$ss", "PLAIN=pa$$word", "PRICE=$5", "TMPL=tmpl ${foo"}
+	if got := keyValues(values); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
@@ -0,0 +1,218 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package polyenvfile
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/withholm/polyenv/internal/model"
+	"github.com/withholm/polyenv/internal/tools"
+)
+
+type ImportStatus string
+
+const (
+	ImportNew       ImportStatus = "new"
+	ImportChanged   ImportStatus = "changed"
+	ImportUnchanged ImportStatus = "unchanged"
+)
+
+// a value to import. Env.File is the file it will be written to
+type ImportItem struct {
+	Env model.StoredEnv
+	//current value, if the key already exists in the environment
+	Existing *model.StoredEnv
+	//why the value is treated as a secret
+	SecretReason string
+}
+
+func (item ImportItem) Status() ImportStatus {
+	if item.Existing == nil {
+		return ImportNew
+	}
+	if item.Existing.Value == item.Env.Value {
+		return ImportUnchanged
+	}
+	return ImportChanged
+}
+
+// list of values to import, sorted by key
+type ImportPlan struct {
+	Items []ImportItem
+}
+
+// returns items with given status
+func (plan ImportPlan) Filter(status ImportStatus) []ImportItem {
+	out := make([]ImportItem, 0)
+	for _, item := range plan.Items {
+		if item.Status() == status {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// figures out where each value should be written.
+// existing keys are updated where they are defined, in the file with the highest precedence if there are more.
+// new keys are written to .env.secret.{env} if they are secrets and the option is enabled, else .env.{env}.
+// keys that are vault references (polyenv://) are always written to .env.secret.{env}, like pull, so the reference is kept
+func (file *File) PlanImport(values []model.StoredEnv) (ImportPlan, error) {
+	existing, err := file.AllDotenvValues()
+	if err != nil {
+		return ImportPlan{}, fmt.Errorf("failed to read existing env: %w", err)
+	}
+	refs, err := file.VaultReferences()
+	if err != nil {
+		return ImportPlan{}, err
+	}
+
+	// keys in multiple files are updated in the file that wins
+	existingMap := make(map[string]model.StoredEnv)
+	for _, env := range Resolve(existing) {
+		existingMap[env.Key] = env
+	}
+
+	envFile, err := file.importTargetFile(".env")
+	if err != nil {
+		return ImportPlan{}, err
+	}
+	secretFile, err := file.importTargetFile(".env.secret")
+	if err != nil {
+		return ImportPlan{}, err
+	}
+
+	plan := ImportPlan{}
+	seen := make(map[string]bool)
+	for _, val := range values {
+		if seen[val.Key] {
+			return ImportPlan{}, fmt.Errorf("key %s is defined multiple times in input", val.Key)
+		}
+		seen[val.Key] = true
+
+		item := ImportItem{Env: model.StoredEnv{Key: val.Key, Value: val.Value}}
+		_, isRef := refs[val.Key]
+		switch {
+		case isRef:
+			item.SecretReason = "vault reference"
+		case val.IsSecret:
+			item.SecretReason = "secret in source"
+		case file.isSecretReference(val.Key):
+			item.SecretReason = "secret reference in polyenv file"
+		default:
+			isSecret, reason := val.DetectSecret()
+			if isSecret {
+				item.SecretReason = reason
+			}
+		}
+		item.Env.IsSecret = item.SecretReason != ""
+
+		if isRef {
+			item.Env.File = secretFile
+			// the reference is not the current value, a pulled value in the secret file is
+			for _, cur := range existing {
+				if cur.Key == val.Key && cur.File == secretFile {
+					item.Existing = &cur
+				}
+			}
+			slog.Debug("vault reference, importing to secret file", "key", val.Key, "file", secretFile)
+		} else if cur, ok := existingMap[val.Key]; ok {
+			item.Existing = &cur
+			item.Env.File = cur.File
+		} else if item.Env.IsSecret && file.Options.UseDotSecretFileForSecrets {
+			item.Env.File = secretFile
+		} else {
+			item.Env.File = envFile
+		}
+		plan.Items = append(plan.Items, item)
+	}
+
+	sort.SliceStable(plan.Items, func(i, j int) bool {
+		return plan.Items[i].Env.Key < plan.Items[j].Env.Key
+	})
+	return plan, nil
+}
+
+// writes the plan to disk. if skipExisting is set, changed values are not overwritten
+func (plan ImportPlan) Apply(skipExisting bool) error {
+	byFile := make(map[string][]model.StoredEnv)
+	for _, item := range plan.Items {
+		switch item.Status() {
+		case ImportUnchanged:
+			continue
+		case ImportChanged:
+			if skipExisting {
+				slog.Debug("skipping existing key", "key", item.Env.Key, "file", item.Env.File)
+				continue
+			}
+		}
+		byFile[item.Env.File] = append(byFile[item.Env.File], item.Env)
+	}
+
+	for path, envs := range byFile {
+		mode := os.FileMode(0o644)
+		if strings.Contains(filepath.Base(path), ".secret") {
+			mode = 0o600
+		}
+		_, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			slog.Debug("creating file", "file", path)
+			err = os.WriteFile(path, []byte{}, mode)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", path, err)
+		}
+
+		current, err := model.ReadDotenv(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		for _, env := range envs {
+			slog.Debug("importing", "key", env.Key, "file", path)
+			current[env.Key] = model.EscapeRaw(env.Value)
+		}
+		err = model.WriteDotenv(current, path)
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// returns true if key is pulled from a vault
+func (file *File) isSecretReference(key string) bool {
+	for _, s := range file.Secrets {
+		if s.LocalKey == key || s.KeyLocalKey == key {
+			return true
+		}
+	}
+	return false
+}
+
+// returns path of given env file type for the environment. uses the existing file closest to the root, else a new file at the root
+func (file *File) importTargetFile(extension string) (string, error) {
+	root, err := tools.GetGitRootOrCwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get project root: %w", err)
+	}
+	name := extension
+	if file.Name != "" {
+		name = file.GenerateFileName(extension)
+	}
+	found, err := tools.GetAllFiles(root, []string{name}, tools.MatchNameIExact)
+	if err != nil {
+		return "", err
+	}
+	if len(found) == 0 {
+		return filepath.Join(root, name), nil
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		return strings.Count(found[i], string(filepath.Separator)) < strings.Count(found[j], string(filepath.Separator))
+	})
+	return found[0], nil
+}
@@ -0,0 +1,220 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package polyenvfile
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/joho/godotenv"
+	"github.com/withholm/polyenv/internal/model"
+	"github.com/withholm/polyenv/internal/vaults/devvault"
+)
+
+func TestImport(t *testing.T) {
+	tmpDir := t.TempDir()
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get current working directory: %v", err)
+	}
+	defer func() {
+		if err := os.Chdir(originalWd); err != nil {
+			t.Fatalf("failed to restore working directory: %v", err)
+		}
+	}()
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatalf("failed to change directory: %v", err)
+	}
+
+	// existing value lives in a sub directory
+	err = os.MkdirAll(filepath.Join(tmpDir, "sub"), 0o755)
+	if err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	existingFile := filepath.Join(tmpDir, "sub", ".env.dev")
+	err = os.WriteFile(existingFile, []byte("A=old\nC=same\n"), 0o644)
+	if err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+
+	file := File{
+		Name:    "dev",
+		Options: VaultOptions{UseDotSecretFileForSecrets: true},
+		Secrets: map[string]model.Secret{
+			"VAULTED": {LocalKey: "VAULTED", RemoteKey: "vaulted"},
+		},
+	}
+
+	plan, err := file.PlanImport([]model.StoredEnv{
+		{Key: "C", Value: "same"},
+		{Key: "A", Value: "new"},
+		{Key: "B", Value: "b"},
+		{Key: "API_KEY", Value: "x"},
+		{Key: "VAULTED", Value: "v"},
+	})
+	if err != nil {
+		t.Fatalf("PlanImport() returned an error: %v", err)
+	}
+
+	expect := map[string]struct {
+		status ImportStatus
+		file   string
+		secret bool
+	}{
+		"A":       {ImportChanged, existingFile, false},
+		"API_KEY": {ImportNew, filepath.Join(tmpDir, ".env.secret.dev"), true},
+		"B":       {ImportNew, existingFile, false},
+		"C":       {ImportUnchanged, existingFile, false},
+		"VAULTED": {ImportNew, filepath.Join(tmpDir, ".env.secret.dev"), true},
+	}
+	if len(plan.Items) != len(expect) {
+		t.Fatalf("expected %d items, got %d", len(expect), len(plan.Items))
+	}
+	for i, item := range plan.Items {
+		if i > 0 && plan.Items[i-1].Env.Key > item.Env.Key {
+			t.Errorf("expected items to be sorted by key")
+		}
+		exp := expect[item.Env.Key]
+		if item.Status() != exp.status {
+			t.Errorf("%s: expected status %s, got %s", item.Env.Key, exp.status, item.Status())
+		}
+		if item.Env.File != exp.file {
+			t.Errorf("%s: expected file %s, got %s", item.Env.Key, exp.file, item.Env.File)
+		}
+		if item.Env.IsSecret != exp.secret {
+			t.Errorf("%s: expected secret %t, got %t", item.Env.Key, exp.secret, item.Env.IsSecret)
+		}
+	}
+
+	err = plan.Apply(true)
+	if err != nil {
+		t.Fatalf("Apply() returned an error: %v", err)
+	}
+
+	got, err := godotenv.Read(existingFile)
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+	if got["A"] != "old" || got["B"] != "b" || got["C"] != "same" {
+		t.Errorf("unexpected content of %s: %v", existingFile, got)
+	}
+
+	secretFile := filepath.Join(tmpDir, ".env.secret.dev")
+	stat, err := os.Stat(secretFile)
+	if err != nil {
+		t.Fatalf("expected secret file to be created: %v", err)
+	}
+	if runtime.GOOS != "windows" && stat.Mode().Perm() != 0o600 {
+		t.Errorf("expected secret file to have 0600, got %o", stat.Mode().Perm())
+	}
+
+	_, err = file.PlanImport([]model.StoredEnv{{Key: "X", Value: "1"}, {Key: "X", Value: "2"}})
+	if err == nil {
+		t.Errorf("expected error on duplicate keys in input")
+	}
+}
+
+func TestImportUpdatesWinningFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get current working directory: %v", err)
+	}
+	defer func() {
+		if err := os.Chdir(originalWd); err != nil {
+			t.Fatalf("failed to restore working directory: %v", err)
+		}
+	}()
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatalf("failed to change directory: %v", err)
+	}
+
+	err = os.MkdirAll(filepath.Join(tmpDir, "sub"), 0o755)
+	if err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	err = os.WriteFile(filepath.Join(tmpDir, ".env.dev"), []byte("X=root\n"), 0o644)
+	if err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	deeper := filepath.Join(tmpDir, "sub", ".env.dev")
+	err = os.WriteFile(deeper, []byte("X=sub\n"), 0o644)
+	if err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+
+	file := File{Name: "dev"}
+	plan, err := file.PlanImport([]model.StoredEnv{{Key: "X", Value: "new"}})
+	if err != nil {
+		t.Fatalf("PlanImport() returned an error: %v", err)
+	}
+	if len(plan.Items) != 1 {
+		t.Fatalf("expected 1 item, got %d", len(plan.Items))
+	}
+	item := plan.Items[0]
+	if item.Env.File != deeper || item.Existing == nil || item.Existing.Value != "sub" {
+		t.Errorf("expected X to be updated in %s, got %s (existing %+v)", deeper, item.Env.File, item.Existing)
+	}
+}
+
+func TestImportKeepsVaultReferences(t *testing.T) {
+	tmpDir := t.TempDir()
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get current working directory: %v", err)
+	}
+	defer func() {
+		if err := os.Chdir(originalWd); err != nil {
+			t.Fatalf("failed to restore working directory: %v", err)
+		}
+	}()
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatalf("failed to change directory: %v", err)
+	}
+
+	envFile := filepath.Join(tmpDir, ".env.dev")
+	err = os.WriteFile(envFile, []byte("DB_PASS=polyenv://kv/db-pass\n"), 0o644)
+	if err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+
+	// the reference is kept, also when secrets are not written to .env.secret
+	for _, useSecretFile := range []bool{true, false} {
+		file := File{
+			Name:    "dev",
+			Vaults:  map[string]model.Vault{"kv": &devvault.Client{Name: "mystore"}},
+			Options: VaultOptions{UseDotSecretFileForSecrets: useSecretFile},
+		}
+		plan, err := file.PlanImport([]model.StoredEnv{{Key: "DB_PASS", Value: "hunter2-plain"}})
+		if err != nil {
+			t.Fatalf("PlanImport() returned an error: %v", err)
+		}
+		secretFile := filepath.Join(tmpDir, ".env.secret.dev")
+		item := plan.Items[0]
+		if item.Env.File != secretFile || !item.Env.IsSecret {
+			t.Errorf("expected DB_PASS to be a secret imported to %s, got %+v", secretFile, item.Env)
+		}
+		err = plan.Apply(false)
+		if err != nil {
+			t.Fatalf("Apply() returned an error: %v", err)
+		}
+
+		got, err := godotenv.Read(envFile)
+		if err != nil {
+			t.Fatalf("failed to read file: %v", err)
+		}
+		if got["DB_PASS"] != "polyenv://kv/db-pass" {
+			t.Errorf("expected reference to be kept, got %q", got["DB_PASS"])
+		}
+		got, err = godotenv.Read(secretFile)
+		if err != nil {
+			t.Fatalf("failed to read file: %v", err)
+		}
+		if got["DB_PASS"] != "hunter2-plain" {
+			t.Errorf("expected value in secret file, got %q", got["DB_PASS"])
+		}
+	}
+}
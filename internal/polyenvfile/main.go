@@ -13,7 +13,6 @@ import (
 	"strings"
 
 	"github.com/BurntSushi/toml"
-	"github.com/joho/godotenv"
 	"github.com/withholm/polyenv/internal/model"
 	"github.com/withholm/polyenv/internal/tools"
 	"github.com/withholm/polyenv/internal/vaults"
@@ -218,9 +217,37 @@ func (file *File) GetSecretInfo(remoteKey string, vault string) (model.Secret, b
 
 // return all dotenv keys in the project in files that include current environment
 // {env}.env || .env.{env} || .env.secret.{env} || {env}.env.secret
-func (file *File) AllDotenvValues() (out []model.StoredEnv, err error) {
+//
+// values are sorted by key. if a key is in multiple files, they are ordered by precedence and the last one wins:
+// secret files over env files, then deeper over shallower files (or the other way, see Options.Precedence)
+//
+// references to other keys (${VAR}, ${VAR:-default}) are expanded over all files, see expandValues.
+// vault references (polyenv://{vault}/{key}) are kept as is and marked as secret, see AllDotenvValuesWithSecrets
+func (file *File) AllDotenvValues() ([]model.StoredEnv, error) {
+	out, err := file.rawDotenvValues()
+	if err != nil {
+		return nil, err
+	}
+	return expandValues(out)
+}
+
+// same as AllDotenvValues, but references to other keys are kept as written
+func (file *File) AllDotenvValuesUnexpanded() ([]model.StoredEnv, error) {
+	out, err := file.rawDotenvValues()
+	if err != nil {
+		return nil, err
+	}
+	return unexpandedValues(out), nil
+}
+
+// values as written in the files, see model.ReadDotenv
+func (file *File) rawDotenvValues() (out []model.StoredEnv, err error) {
 	configEnv := file.Name
 	slog.Debug("getting all dotenv values within", "env", configEnv)
+	err = file.Options.Precedence.Validate()
+	if err != nil {
+		return nil, err
+	}
 	// get all files
 	cwd, err := tools.GetGitRootOrCwd()
 	if err != nil {
@@ -236,10 +263,7 @@ func (file *File) AllDotenvValues() (out []model.StoredEnv, err error) {
 		return nil, err
 	}
 
-	// sort by amount of /
-	sort.Slice(allfiles, func(i, j int) bool {
-		return strings.Count(allfiles[i], "/") > strings.Count(allfiles[j], "/")
-	})
+	sortByPrecedence(allfiles, file.Options.Precedence)
 
 	slog.Debug("filtering dotenv for", "env", configEnv)
 
@@ -255,30 +279,40 @@ func (file *File) AllDotenvValues() (out []model.StoredEnv, err error) {
 		}
 		slog.Debug("found dotenv", "file", fl)
 
-		m, e := godotenv.Read(fl)
+		m, e := model.ReadDotenv(fl)
 		if e != nil {
 			slog.Error("failed to read file", "error", e)
 			os.Exit(1)
 		}
 
-		for k, v := range m {
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
 			slog.Debug("dotenv value", "key", k)
-			isSecret := false
+			isSecret := model.IsVaultReference(m[k])
 			for _, s := range file.Secrets {
-				if s.LocalKey == k {
+				if s.LocalKey == k || s.KeyLocalKey == k {
 					isSecret = true
 					break
 				}
 			}
 			out = append(out, model.StoredEnv{
 				Key:      k,
-				Value:    v,
+				Value:    m[k],
 				File:     fl,
 				IsSecret: isSecret,
 			})
 		}
 	}
 
+	// stable, so duplicates stay in precedence order
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Key < out[j].Key
+	})
 	return out, nil
 }
 
@@ -214,3 +214,87 @@ func TestFile_GetSecretInfo(t *testing.T) {
 		t.Error("GetSecretInfo() found a non-existent secret")
 	}
 }
+
+func TestFile_AllDotenvValues(t *testing.T) {
+	tmpDir := t.TempDir()
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get current working directory: %v", err)
+	}
+	defer func() {
+		if err := os.Chdir(originalWd); err != nil {
+			t.Fatalf("failed to restore working directory: %v", err)
+		}
+	}()
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatalf("failed to change directory: %v", err)
+	}
+
+	files := map[string]string{
+		".env.dev":                                  "B=root\nA=root\nSHARED=root\n",
+		".env.secret.dev":                           "SHARED=secret\n",
+		filepath.Join("sub", ".env.dev"):            "A=sub\nC=sub\n",
+		filepath.Join("sub", "deeper", ".env.dev"):  "A=deeper\n",
+		filepath.Join("sub", "deeper", "other.env"): "IGNORED=1\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+
+	testCases := []struct {
+		name       string
+		precedence Precedence
+		expected   []string
+		resolved   []string
+	}{
+		{
+			name:     "default deeper wins",
+			expected: []string{"A=root", "A=sub", "A=deeper", "B=root", "C=sub", "SHARED=root", "SHARED=secret"},
+			resolved: []string{"A=deeper", "B=root", "C=sub", "SHARED=secret"},
+		},
+		{
+			name:       "shallower wins",
+			precedence: PrecedenceShallower,
+			expected:   []string{"A=deeper", "A=sub", "A=root", "B=root", "C=sub", "SHARED=root", "SHARED=secret"},
+			resolved:   []string{"A=root", "B=root", "C=sub", "SHARED=secret"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			file := File{Name: "dev", Options: VaultOptions{Precedence: tc.precedence}}
+			// run a few times, map iteration should not change the order
+			for range 5 {
+				values, err := file.AllDotenvValues()
+				if err != nil {
+					t.Fatalf("AllDotenvValues() returned an error: %v", err)
+				}
+				if got := keyValues(values); !reflect.DeepEqual(got, tc.expected) {
+					t.Fatalf("expected %v, got %v", tc.expected, got)
+				}
+				if got := keyValues(Resolve(values)); !reflect.DeepEqual(got, tc.resolved) {
+					t.Fatalf("expected resolved %v, got %v", tc.resolved, got)
+				}
+			}
+		})
+	}
+
+	file := File{Name: "dev", Options: VaultOptions{Precedence: "nope"}}
+	if _, err := file.AllDotenvValues(); err == nil {
+		t.Error("expected error on unknown precedence")
+	}
+}
+
+func keyValues(values []model.StoredEnv) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		out = append(out, v.Key+"="+v.Value)
+	}
+	return out
+}
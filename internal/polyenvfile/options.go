@@ -15,6 +15,8 @@ type VaultOptions struct {
 	HyphenToUnderscore         bool `toml:"hyphens_to_underscores"`
 	UppercaseLocally           bool `toml:"uppercase_locally"`
 	UseDotSecretFileForSecrets bool `toml:"use_dot_secret_file_for_secrets"`
+	//which file wins when a key is in multiple files. 'deeper' (default) or 'shallower'
+	Precedence Precedence `toml:"precedence,omitempty"`
 }
 
 type VaultOptionHelper struct {
@@ -61,6 +63,19 @@ func (opt VaultOptions) GetVaultOptionHelper() map[string]VaultOptionHelper {
 				"\n",
 			),
 		},
+		"precedence": {
+			Name:  "precedence",
+			Value: opt.Precedence,
+			Summary: strings.Join(
+				[]string{
+					"Which file wins when a key is defined in multiple env files.",
+					".env.secret files always win over .env files.",
+					"deeper: files in sub directories win (default)",
+					"shallower: files closer to the git root win",
+				},
+				"\n",
+			),
+		},
 	}
 }
 
@@ -88,6 +103,9 @@ func (opt VaultOptions) ListCurrentOptions() string {
 		items["dotEnvSecrets"].Name, List.New(
 			bold.Render(toPrettyBool(opt.UseDotSecretFileForSecrets)),
 		),
+		items["precedence"].Name, List.New(
+			bold.Render(string(opt.effectivePrecedence())),
+		),
 	)
 	return currentOptList.String()
 }
@@ -116,6 +134,19 @@ func (opt VaultOptions) TuiOpts() *huh.Form {
 				Affirmative("Yes").
 				Negative("No").
 				Value(&opt.UseDotSecretFileForSecrets).WithButtonAlignment(lipgloss.Left),
+			huh.NewSelect[Precedence]().
+				Description(optMap["precedence"].Summary).
+				Title(optMap["precedence"].Name).
+				Options(huh.NewOptions(Precedences...)...).
+				Value(&opt.Precedence),
 		),
 	)
 }
+
+// precedence, with the default filled in
+func (opt VaultOptions) effectivePrecedence() Precedence {
+	if opt.Precedence == "" {
+		return PrecedenceDeeper
+	}
+	return opt.Precedence
+}
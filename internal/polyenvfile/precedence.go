@@ -0,0 +1,75 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package polyenvfile
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/withholm/polyenv/internal/model"
+)
+
+// decides which file wins when a key is defined in multiple files.
+// secret files always win over env files, this decides between files of the same kind
+type Precedence string
+
+const (
+	// files in sub directories win over files closer to the git root (default)
+	PrecedenceDeeper Precedence = "deeper"
+	// files closer to the git root win over files in sub directories
+	PrecedenceShallower Precedence = "shallower"
+)
+
+var Precedences = []Precedence{PrecedenceDeeper, PrecedenceShallower}
+
+func (p Precedence) Validate() error {
+	if p == "" || slices.Contains(Precedences, p) {
+		return nil
+	}
+	return fmt.Errorf("unknown precedence '%s'. available: %v", p, Precedences)
+}
+
+// true for .env.secret and .env.secret.{env}
+func isSecretFile(path string) bool {
+	return strings.HasPrefix(strings.ToLower(filepath.Base(path)), ".env.secret")
+}
+
+// sorts files from lowest to highest precedence, so values of later files wins.
+// secret files over env files, then by depth, then by path so the order is always the same
+func sortByPrecedence(files []string, precedence Precedence) {
+	slices.SortFunc(files, func(a, b string) int {
+		if sa, sb := isSecretFile(a), isSecretFile(b); sa != sb {
+			if sa {
+				return 1
+			}
+			return -1
+		}
+		da := strings.Count(filepath.ToSlash(a), "/")
+		db := strings.Count(filepath.ToSlash(b), "/")
+		if da != db {
+			if precedence == PrecedenceShallower {
+				return db - da
+			}
+			return da - db
+		}
+		return strings.Compare(a, b)
+	})
+}
+
+// keeps the effective value of each key. values has to be in precedence order, like AllDotenvValues returns them
+func Resolve(values []model.StoredEnv) []model.StoredEnv {
+	last := make(map[string]int, len(values))
+	for i, v := range values {
+		last[v.Key] = i
+	}
+	out := make([]model.StoredEnv, 0, len(last))
+	for i, v := range values {
+		if last[v.Key] == i {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,114 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package polyenvfile
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/withholm/polyenv/internal/model"
+)
+
+// same as AllDotenvValues, but values that are vault references (polyenv://{vault}/{key}) are pulled from the vault.
+// only references that are used are pulled: the effective value of a key, or referenced by one
+func (file *File) AllDotenvValuesWithSecrets(ctx context.Context) ([]model.StoredEnv, error) {
+	out, err := file.rawDotenvValues()
+	if err != nil {
+		return nil, err
+	}
+	return expandValuesWith(out, file.vaultReferencePuller(ctx))
+}
+
+// returns secrets referenced inline in the env files (KEY=polyenv://{vault}/{key}), by local key.
+// if a key has references in multiple files, the one with the highest precedence is used
+func (file *File) VaultReferences() (map[string]model.Secret, error) {
+	values, err := file.rawDotenvValues()
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]model.Secret)
+	for _, v := range values {
+		s, ok, err := model.ParseVaultReference(v.Key, model.UnescapeRaw(v.Value))
+		if err != nil {
+			return nil, fmt.Errorf("%s in %s: %w", v.Key, v.File, err)
+		}
+		if !ok {
+			continue
+		}
+		if _, ok := file.Vaults[s.Vault]; !ok {
+			return nil, fmt.Errorf("%s in %s: vault %s is not defined in %s", v.Key, v.File, s.Vault, file.Fullname())
+		}
+		out[v.Key] = s
+	}
+	return out, nil
+}
+
+// returns a function that pulls vault references. vaults are warmed up once
+func (file *File) vaultReferencePuller(ctx context.Context) func(model.Secret) (string, error) {
+	warm := make(map[string]bool)
+	return func(s model.Secret) (string, error) {
+		vault, ok := file.Vaults[s.Vault]
+		if !ok {
+			return "", fmt.Errorf("vault %s is not defined in %s", s.Vault, file.Fullname())
+		}
+		if !warm[s.Vault] {
+			err := vault.Warmup()
+			if err != nil {
+				return "", fmt.Errorf("failed to warmup vault %s: %w", s.Vault, err)
+			}
+			warm[s.Vault] = true
+		}
+		slog.Debug("pulling vault reference", "key", s.LocalKey, "vault", s.Vault, "secret", s.RemoteKey)
+		return s.GetContent(ctx, vault)
+	}
+}
+
+// sets key to a vault reference (polyenv://{vault}/{remoteKey}), without pulling the value.
+// the key is updated where it is defined, else it is added to .env.{env}. secret files are skipped, as pull writes to them
+func (file *File) AddVaultReference(key string, vault string, remoteKey string) (model.StoredEnv, error) {
+	if _, ok := file.Vaults[vault]; !ok {
+		return model.StoredEnv{}, fmt.Errorf("vault %s is not defined in %s", vault, file.Fullname())
+	}
+	if remoteKey == "" {
+		return model.StoredEnv{}, fmt.Errorf("remote key cannot be empty")
+	}
+	key = file.Options.ConvertString(key)
+	if !isReferenceName(key) {
+		return model.StoredEnv{}, fmt.Errorf("invalid key %s: only letters, numbers, '_' and '.' are allowed", key)
+	}
+	if _, ok := file.Secrets[key]; ok {
+		return model.StoredEnv{}, fmt.Errorf("%s is already a secret in %s", key, file.Fullname())
+	}
+
+	values, err := file.rawDotenvValues()
+	if err != nil {
+		return model.StoredEnv{}, err
+	}
+	env := model.StoredEnv{Key: key, Value: model.VaultReference(vault, remoteKey), IsSecret: true}
+	for _, v := range values {
+		if v.Key == key && !isSecretFile(v.File) {
+			env.File = v.File
+		}
+	}
+	if env.File == "" {
+		env.File, err = file.importTargetFile(".env")
+		if err != nil {
+			return model.StoredEnv{}, err
+		}
+		if _, err := os.Stat(env.File); os.IsNotExist(err) {
+			err = os.WriteFile(env.File, []byte{}, 0o644)
+			if err != nil {
+				return model.StoredEnv{}, fmt.Errorf("failed to create %s: %w", env.File, err)
+			}
+		}
+	}
+
+	err = env.Save()
+	if err != nil {
+		return model.StoredEnv{}, err
+	}
+	return env, nil
+}
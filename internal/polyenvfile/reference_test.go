@@ -0,0 +1,119 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package polyenvfile
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/withholm/polyenv/internal/model"
+	"github.com/withholm/polyenv/internal/vaults/devvault"
+)
+
+func TestVaultReferences(t *testing.T) {
+	tmpDir := t.TempDir()
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get current working directory: %v", err)
+	}
+	defer func() {
+		if err := os.Chdir(originalWd); err != nil {
+			t.Fatalf("failed to restore working directory: %v", err)
+		}
+	}()
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatalf("failed to change directory: %v", err)
+	}
+
+	content := "DATABASE_URL=\"postgres://app:${DB_PASS}@db\"\nDB_PASS=polyenv://dev/mykey\nUNUSED=polyenv://dev/missing\n"
+	err = os.WriteFile(filepath.Join(tmpDir, ".env.dev"), []byte(content), 0o644)
+	if err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	// pulled value of UNUSED wins over the reference
+	err = os.WriteFile(filepath.Join(tmpDir, ".env.secret.dev"), []byte("UNUSED=pulled\n"), 0o600)
+	if err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	file := File{
+		Name:    "dev",
+		Path:    tmpDir,
+		Vaults:  map[string]model.Vault{"dev": &devvault.Client{Name: "mystore"}},
+		Options: VaultOptions{HyphenToUnderscore: true},
+	}
+
+	refs, err := file.VaultReferences()
+	if err != nil {
+		t.Fatalf("VaultReferences() returned an error: %v", err)
+	}
+	expected := map[string]model.Secret{
+		"DB_PASS": {Vault: "dev", RemoteKey: "mykey", LocalKey: "DB_PASS"},
+		"UNUSED":  {Vault: "dev", RemoteKey: "missing", LocalKey: "UNUSED"},
+	}
+	if !reflect.DeepEqual(refs, expected) {
+		t.Errorf("expected %v, got %v", expected, refs)
+	}
+
+	// references are kept, but marked as secret
+	values, err := file.AllDotenvValues()
+	if err != nil {
+		t.Fatalf("AllDotenvValues() returned an error: %v", err)
+	}
+	for _, v := range values {
+		if v.Key == "DB_PASS" && (!v.IsSecret || v.Value != "polyenv://dev/mykey") {
+			t.Errorf("expected DB_PASS to be an unpulled secret, got %+v", v)
+		}
+	}
+
+	values, err = file.AllDotenvValuesWithSecrets(context.Background())
+	if err != nil {
+		t.Fatalf("AllDotenvValuesWithSecrets() returned an error: %v", err)
+	}
+	expectedValues := []string{
+		"DATABASE_URL=postgres://app:myvalue@db",
+		"DB_PASS=myvalue",
+		"UNUSED=polyenv://dev/missing",
+		"UNUSED=pulled",
+	}
+	if got := keyValues(values); !reflect.DeepEqual(got, expectedValues) {
+		t.Errorf("expected %v, got %v", expectedValues, got)
+	}
+	if !values[0].IsSecret {
+		t.Errorf("expected DATABASE_URL to be a secret, as it references one")
+	}
+
+	env, err := file.AddVaultReference("new-key", "dev", "other")
+	if err != nil {
+		t.Fatalf("AddVaultReference() returned an error: %v", err)
+	}
+	if env.Key != "new_key" || env.File != filepath.Join(tmpDir, ".env.dev") || env.Value != "polyenv://dev/other" {
+		t.Errorf("unexpected reference: %+v", env)
+	}
+	// pulled value in the secret file is not replaced
+	env, err = file.AddVaultReference("UNUSED", "dev", "other")
+	if err != nil {
+		t.Fatalf("AddVaultReference() returned an error: %v", err)
+	}
+	if env.File != filepath.Join(tmpDir, ".env.dev") {
+		t.Errorf("expected UNUSED to be updated in .env.dev, got %s", env.File)
+	}
+	if _, err := file.AddVaultReference("X", "nope", "other"); err == nil {
+		t.Errorf("expected error on unknown vault")
+	}
+	if _, err := file.AddVaultReference("A B", "dev", "other"); err == nil {
+		t.Errorf("expected error on invalid key")
+	}
+
+	err = os.WriteFile(filepath.Join(tmpDir, ".env.dev"), []byte("BAD=polyenv://dev\n"), 0o644)
+	if err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if _, err := file.VaultReferences(); err == nil {
+		t.Errorf("expected error on invalid reference")
+	}
+}
@@ -4,6 +4,7 @@
 package polyenvfile
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
@@ -185,7 +186,7 @@ func (file *File) TuiAddSecret(vaultName string) {
 					Title("Select secret(s)").
 					Description("Multiple secrets can be selected. secrets with '!' are not enabled.").
 					OptionsFunc(func() (opt []huh.Option[model.Secret]) {
-						e := v.ListElevate()
+						e := v.ListElevate(context.Background())
 						if e != nil {
 							slog.Error("failed to elevate permissions", "error", e)
 							os.Exit(1)
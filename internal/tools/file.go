@@ -170,3 +170,49 @@ func ExtractNameFromDotenv(filename string) (string, error) {
 
 	return ret, nil
 }
+
+// writes data to a temp file in the same directory and renames it over path.
+// missing directories are created
+func WriteFileAtomic(path string, data []byte, perm fs.FileMode) error {
+	dir := filepath.Dir(path)
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	// no-op when renamed
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	// set permissions before any data is written
+	err = tmp.Chmod(perm)
+	if err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to set permissions on temp file: %w", err)
+	}
+	_, err = tmp.Write(data)
+	if err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	err = tmp.Sync()
+	if err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to sync temp file: %w", err)
+	}
+	err = tmp.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+
+	err = os.Rename(tmp.Name(), path)
+	if err != nil {
+		return fmt.Errorf("failed to move temp file to %s: %w", path, err)
+	}
+	return nil
+}
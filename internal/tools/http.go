@@ -18,9 +18,21 @@ import (
 // DefaultTimeout is the default timeout for the HTTP client.
 const DefaultTimeout = 30 * time.Second
 
+// StatusError is returned when the server responds with a non-2xx status code.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("received non-2xx status code %d: %s", e.StatusCode, e.Body)
+}
+
 // PolyenvHTTPClient is a wrapper around http.PolyenvHTTPClient to provide convenience methods.
 type PolyenvHTTPClient struct {
 	httpClient *http.Client
+	username   string
+	password   string
 }
 
 // NewPolyenvHTTPClient creates a new HTTP client with default settings.
@@ -32,6 +44,13 @@ func NewPolyenvHTTPClient() *PolyenvHTTPClient {
 	}
 }
 
+// WithBasicAuth sets basic auth credentials on all requests.
+func (c *PolyenvHTTPClient) WithBasicAuth(username, password string) *PolyenvHTTPClient {
+	c.username = username
+	c.password = password
+	return c
+}
+
 func (c *PolyenvHTTPClient) ValidateTarget(target interface{}) (reflect.Type, error) {
 	if target == nil {
 		return nil, nil
@@ -57,7 +76,7 @@ func (c *PolyenvHTTPClient) NewRequest(ctx context.Context, method string, url s
 		bod = bytes.NewBuffer(jsonBody)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, bod)
+	req, err := http.NewRequestWithContext(ctx, method, url, bod)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -65,6 +84,9 @@ func (c *PolyenvHTTPClient) NewRequest(ctx context.Context, method string, url s
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("User-Agent", "polyenv-cli-ty-for-making-an-awesome-product")
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
 
 	return req, nil
 }
@@ -102,7 +124,7 @@ func (c *PolyenvHTTPClient) Get(ctx context.Context, url string, target interfac
 
 	// Check for non-successful status codes
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("received non-2xx status code %d: %s", resp.StatusCode, string(bodyBytes))
+		return &StatusError{StatusCode: resp.StatusCode, Body: string(bodyBytes)}
 	}
 
 	// Unmarshal the response body if a target is provided
@@ -156,7 +178,7 @@ func (c *PolyenvHTTPClient) Post(ctx context.Context, url string, body interface
 
 	// Check for non-successful status codes
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("received non-2xx status code %d: %s", resp.StatusCode, string(bodyBytes))
+		return &StatusError{StatusCode: resp.StatusCode, Body: string(bodyBytes)}
 	}
 
 	// Unmarshal the response body if a target is provided
@@ -7,6 +7,7 @@
 package devvault
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"slices"
@@ -193,7 +194,7 @@ func (c *Client) Push(s model.SecretContent) error {
 	return nil
 }
 
-func (c *Client) PushElevate() error {
+func (c *Client) PushElevate(ctx context.Context) error {
 	return nil
 }
 
@@ -208,13 +209,13 @@ func (c *Client) Pull(s model.Secret) (model.SecretContent, error) {
 	return sec, nil
 }
 
-func (c *Client) PullElevate() error {
+func (c *Client) PullElevate(ctx context.Context) error {
 	return nil
 }
 
 //region List
 
-func (c *Client) ListElevate() error {
+func (c *Client) ListElevate(ctx context.Context) error {
 	return nil
 }
 
@@ -0,0 +1,184 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package keyvault
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azkeys"
+	"github.com/withholm/polyenv/internal/model"
+)
+
+const (
+	contentTypePkcs12 = "application/x-pkcs12"
+	contentTypePem    = "application/x-pem-file"
+)
+
+type azkeysClient interface {
+	GetKey(ctx context.Context, name string, version string, options *azkeys.GetKeyOptions) (azkeys.GetKeyResponse, error)
+}
+
+// pulls a certificate as pem (certificate chain + private key).
+// keyvault exposes the certificate with its private key as a secret with the same name
+func (cli *Client) pullCertificate(s model.Secret) (model.SecretContent, error) {
+	sec, err := cli.pullSecret(s)
+	if err != nil {
+		return model.SecretContent{}, err
+	}
+
+	value, err := certificateToPem(sec.ContentType, sec.Value)
+	if err != nil {
+		return model.SecretContent{}, fmt.Errorf("failed to convert certificate %s to pem: %w", s.RemoteKey, err)
+	}
+	sec.Value = value
+	sec.ContentType = contentTypePem
+	return sec, nil
+}
+
+// converts a certificate secret value to pem. keyvault stores it either as base64 pkcs12 or as pem
+func certificateToPem(contentType, value string) (string, error) {
+	switch contentType {
+	case contentTypePem:
+		// re-encode to normalize line endings and drop anything that is not a pem block
+		certs, key, err := model.SplitPEM(value)
+		if err != nil {
+			return "", err
+		}
+		return certs + key, nil
+	case contentTypePkcs12, "":
+		data, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return "", fmt.Errorf("failed to decode pkcs12: %w", err)
+		}
+		certs, key, err := azidentity.ParseCertificates(data, nil)
+		if err != nil {
+			return "", err
+		}
+
+		var buf bytes.Buffer
+		for _, c := range certs {
+			err = pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})
+			if err != nil {
+				return "", err
+			}
+		}
+		if key != nil {
+			der, err := x509.MarshalPKCS8PrivateKey(key)
+			if err != nil {
+				return "", fmt.Errorf("failed to marshal private key: %w", err)
+			}
+			err = pem.Encode(&buf, &pem.Block{Type: "PRIVATE KEY", Bytes: der})
+			if err != nil {
+				return "", err
+			}
+		}
+		return buf.String(), nil
+	}
+	return "", fmt.Errorf("unsupported content type '%s'", contentType)
+}
+
+// pulls the public part of a key as pem
+func (cli *Client) pullKey(s model.Secret) (model.SecretContent, error) {
+	var sec model.SecretContent
+	if cli.keys == nil {
+		return sec, fmt.Errorf("client not initialized. warmup first")
+	}
+
+	kvKey, err := cli.keys.GetKey(context.Background(), s.RemoteKey, s.Version, nil)
+	if err != nil {
+		if s.Version != "" {
+			return sec, fmt.Errorf("failed to read key %s (version %s): %w", s.RemoteKey, s.Version, err)
+		}
+		return sec, fmt.Errorf("failed to read key %s: %w", s.RemoteKey, err)
+	}
+	if kvKey.Key == nil {
+		return sec, fmt.Errorf("key %s has no key material", s.RemoteKey)
+	}
+
+	sec.RemoteKey = s.RemoteKey
+	sec.LocalKey = s.LocalKey
+	sec.ContentType = contentTypePem
+	sec.Tags = convertTags(kvKey.Tags)
+	if kvKey.Key.KID != nil {
+		sec.Version = kvKey.Key.KID.Version()
+	}
+
+	sec.Enabled = true
+	if kvKey.Attributes != nil {
+		if kvKey.Attributes.Enabled != nil {
+			sec.Enabled = *kvKey.Attributes.Enabled
+		}
+		sec.Expires = kvKey.Attributes.Expires
+		sec.NotBefore = kvKey.Attributes.NotBefore
+	}
+
+	err = checkSecretValidity(sec, time.Now())
+	if err != nil {
+		return model.SecretContent{}, err
+	}
+
+	sec.Value, err = publicKeyToPem(kvKey.Key)
+	if err != nil {
+		return model.SecretContent{}, fmt.Errorf("failed to convert key %s to pem: %w", s.RemoteKey, err)
+	}
+	return sec, nil
+}
+
+// converts the public part of a json web key to a pkix pem block
+func publicKeyToPem(jwk *azkeys.JSONWebKey) (string, error) {
+	if jwk.Kty == nil {
+		return "", fmt.Errorf("key type is not set")
+	}
+
+	var pub any
+	switch *jwk.Kty {
+	case azkeys.KeyTypeRSA, azkeys.KeyTypeRSAHSM:
+		if len(jwk.N) == 0 || len(jwk.E) == 0 {
+			return "", fmt.Errorf("rsa key is missing modulus or exponent")
+		}
+		pub = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(jwk.N),
+			E: int(new(big.Int).SetBytes(jwk.E).Int64()),
+		}
+	case azkeys.KeyTypeEC, azkeys.KeyTypeECHSM:
+		if jwk.Crv == nil {
+			return "", fmt.Errorf("ec key is missing curve")
+		}
+		var curve elliptic.Curve
+		switch *jwk.Crv {
+		case azkeys.CurveNameP256:
+			curve = elliptic.P256()
+		case azkeys.CurveNameP384:
+			curve = elliptic.P384()
+		case azkeys.CurveNameP521:
+			curve = elliptic.P521()
+		default:
+			return "", fmt.Errorf("unsupported curve '%s'", *jwk.Crv)
+		}
+		pub = &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(jwk.X),
+			Y:     new(big.Int).SetBytes(jwk.Y),
+		}
+	default:
+		return "", fmt.Errorf("unsupported key type '%s'", *jwk.Kty)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
@@ -0,0 +1,136 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package keyvault
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+)
+
+// type of credential used to authenticate against azure
+type CredentialType string
+
+const (
+	CredentialDefault           CredentialType = "default"
+	CredentialCli               CredentialType = "cli"
+	CredentialManagedIdentity   CredentialType = "managed-identity"
+	CredentialWorkloadIdentity  CredentialType = "workload-identity"
+	CredentialClientSecret      CredentialType = "client-secret"
+	CredentialClientCertificate CredentialType = "client-certificate"
+	CredentialDeviceCode        CredentialType = "device-code"
+)
+
+// secrets used by credentials are never stored in the polyenv file, only read from env
+const (
+	envClientSecret        = "AZURE_CLIENT_SECRET"
+	envClientCertificate   = "AZURE_CLIENT_CERTIFICATE_PATH"
+	envCertificatePassword = "AZURE_CLIENT_CERTIFICATE_PASSWORD"
+)
+
+var credentialTypes = []CredentialType{
+	CredentialDefault,
+	CredentialCli,
+	CredentialManagedIdentity,
+	CredentialWorkloadIdentity,
+	CredentialClientSecret,
+	CredentialClientCertificate,
+	CredentialDeviceCode,
+}
+
+// validates that the credential type is supported. empty is treated as default
+func validateCredentialType(s string) error {
+	if s == "" {
+		return nil
+	}
+	if !slices.Contains(credentialTypes, CredentialType(s)) {
+		return fmt.Errorf("invalid credential '%s'. supported values: %v", s, credentialTypes)
+	}
+	return nil
+}
+
+// returns the credential type of the vault. defaults to 'default'
+func (cli *Client) credentialType() CredentialType {
+	if cli.Credential == "" {
+		return CredentialDefault
+	}
+	return CredentialType(cli.Credential)
+}
+
+// creates a token credential based on the credential type set on the vault
+func (cli *Client) newCredential() (azcore.TokenCredential, error) {
+	typ := cli.credentialType()
+	slog.Debug("creating azure credential", "type", typ, "tenant", cli.Tenant, "client id", cli.ClientID)
+
+	switch typ {
+	case CredentialDefault:
+		return azidentity.NewDefaultAzureCredential(&azidentity.DefaultAzureCredentialOptions{
+			TenantID: cli.Tenant,
+		})
+	case CredentialCli:
+		err := checkAzCliInstalled()
+		if err != nil {
+			return nil, err
+		}
+		return azidentity.NewAzureCLICredential(&azidentity.AzureCLICredentialOptions{
+			TenantID: cli.Tenant,
+		})
+	case CredentialManagedIdentity:
+		opts := &azidentity.ManagedIdentityCredentialOptions{}
+		if cli.ClientID != "" {
+			opts.ID = azidentity.ClientID(cli.ClientID)
+		}
+		return azidentity.NewManagedIdentityCredential(opts)
+	case CredentialWorkloadIdentity:
+		// token file and client id falls back to AZURE_FEDERATED_TOKEN_FILE and AZURE_CLIENT_ID
+		return azidentity.NewWorkloadIdentityCredential(&azidentity.WorkloadIdentityCredentialOptions{
+			TenantID: cli.Tenant,
+			ClientID: cli.ClientID,
+		})
+	case CredentialClientSecret:
+		if cli.ClientID == "" {
+			return nil, fmt.Errorf("credential %s requires client_id to be set on the vault", typ)
+		}
+		secret := os.Getenv(envClientSecret)
+		if secret == "" {
+			return nil, fmt.Errorf("credential %s requires env %s to be set", typ, envClientSecret)
+		}
+		return azidentity.NewClientSecretCredential(cli.Tenant, cli.ClientID, secret, nil)
+	case CredentialClientCertificate:
+		if cli.ClientID == "" {
+			return nil, fmt.Errorf("credential %s requires client_id to be set on the vault", typ)
+		}
+		path := cli.CertificatePath
+		if path == "" {
+			path = os.Getenv(envClientCertificate)
+		}
+		if path == "" {
+			return nil, fmt.Errorf("credential %s requires certificate_path on the vault or env %s", typ, envClientCertificate)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read certificate: %w", err)
+		}
+		var password []byte
+		if pass := os.Getenv(envCertificatePassword); pass != "" {
+			password = []byte(pass)
+		}
+		certs, key, err := azidentity.ParseCertificates(data, password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		return azidentity.NewClientCertificateCredential(cli.Tenant, cli.ClientID, certs, key, nil)
+	case CredentialDeviceCode:
+		return azidentity.NewDeviceCodeCredential(&azidentity.DeviceCodeCredentialOptions{
+			TenantID: cli.Tenant,
+			ClientID: cli.ClientID,
+		})
+	}
+
+	return nil, fmt.Errorf("unsupported credential type: %s", typ)
+}
@@ -18,9 +18,10 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	azlog "github.com/Azure/azure-sdk-for-go/sdk/azcore/log"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
-	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azkeys"
 	azsec "github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
 	"github.com/withholm/polyenv/internal/model"
 )
@@ -41,13 +42,41 @@ type Client struct {
 	//uri of the keyvault
 	URI string `toml:"uri"`
 
-	client azsecretsClient `toml:"-"`
+	//credential used to authenticate. see CredentialType. defaults to 'default'
+	Credential string `toml:"credential"`
+	//client id of app registration or user assigned managed identity
+	ClientID string `toml:"client_id"`
+	//path to pem or pkcs12 certificate used by 'client-certificate'
+	CertificatePath string `toml:"certificate_path"`
+
+	//activate eligible key vault roles using PIM before accessing secrets
+	PIM bool `toml:"pim"`
+	//arm resource id of the keyvault. used as scope for PIM. resolved using resource graph if empty
+	ResourceID string `toml:"resource_id"`
+	//how long PIM activation should last (ISO 8601 duration). defaults to PT1H
+	PIMDuration string `toml:"pim_duration"`
+	//justification used for PIM activation
+	PIMJustification string `toml:"pim_justification"`
+
+	client azsecretsClient        `toml:"-"`
+	keys   azkeysClient           `toml:"-"`
+	cred   azcore.TokenCredential `toml:"-"`
+	pim    pimClient              `toml:"-"`
 
 	wiz Wizard `toml:"-"`
 
-	pullElevateOnce sync.Once `toml:"-"`
-	pushElevateOnce sync.Once `toml:"-"`
-	listElevateOnce sync.Once `toml:"-"`
+	pullElevateOnce    sync.Once `toml:"-"`
+	pullKeyElevateOnce sync.Once `toml:"-"`
+	pushElevateOnce    sync.Once `toml:"-"`
+	listElevateOnce    sync.Once `toml:"-"`
+
+	//result of elevation, cached for the rest of the run
+	pullElevateErr    error `toml:"-"`
+	pullKeyElevateErr error `toml:"-"`
+	pushElevateErr    error `toml:"-"`
+	listElevateErr    error `toml:"-"`
+	//context given to PullElevate. keys are elevated for on the first key pull, as they need other roles
+	pullCtx context.Context `toml:"-"`
 }
 
 // var ctx context.Context
@@ -125,11 +154,33 @@ func checkAzCliInstalled() error {
 }
 
 func (cli *Client) Marshal() map[string]any {
-	return map[string]any{
+	out := map[string]any{
 		"type":   "keyvault",
 		"tenant": cli.Tenant,
 		"uri":    cli.URI,
 	}
+	if cli.Credential != "" {
+		out["credential"] = cli.Credential
+	}
+	if cli.ClientID != "" {
+		out["client_id"] = cli.ClientID
+	}
+	if cli.CertificatePath != "" {
+		out["certificate_path"] = cli.CertificatePath
+	}
+	if cli.ResourceID != "" {
+		out["resource_id"] = cli.ResourceID
+	}
+	if cli.PIM {
+		out["pim"] = true
+		if cli.PIMDuration != "" {
+			out["pim_duration"] = cli.PIMDuration
+		}
+		if cli.PIMJustification != "" {
+			out["pim_justification"] = cli.PIMJustification
+		}
+	}
+	return out
 }
 
 func (cli *Client) Unmarshal(m map[string]any) error {
@@ -144,25 +195,49 @@ func (cli *Client) Unmarshal(m map[string]any) error {
 
 	cli.Tenant = tenant
 	cli.URI = uri
+
+	//optional values
+	if cred, ok := m["credential"].(string); ok {
+		err := validateCredentialType(cred)
+		if err != nil {
+			return err
+		}
+		cli.Credential = cred
+	}
+	if id, ok := m["client_id"].(string); ok {
+		cli.ClientID = id
+	}
+	if path, ok := m["certificate_path"].(string); ok {
+		cli.CertificatePath = path
+	}
+	if id, ok := m["resource_id"].(string); ok {
+		cli.ResourceID = id
+	}
+	if pim, ok := m["pim"].(bool); ok {
+		cli.PIM = pim
+	}
+	if dur, ok := m["pim_duration"].(string); ok && dur != "" {
+		err := validatePimDuration(dur)
+		if err != nil {
+			return err
+		}
+		cli.PIMDuration = dur
+	}
+	if just, ok := m["pim_justification"].(string); ok {
+		cli.PIMJustification = just
+	}
 	return nil
 }
 
 func (cli *Client) Warmup() error {
-	slog.Debug("warming up vault client", "tenant", cli.Tenant, "uri", cli.URI)
-	err := checkAzCliInstalled()
-	if err != nil {
-		return err
-	}
-
+	slog.Debug("warming up vault client", "tenant", cli.Tenant, "uri", cli.URI, "credential", cli.credentialType())
 	if cli.Tenant == "" {
 		return fmt.Errorf("tenant cannot be empty")
 	}
 
-	cred, err := azidentity.NewDefaultAzureCredential(&azidentity.DefaultAzureCredentialOptions{
-		TenantID: cli.Tenant,
-	})
+	cred, err := cli.newCredential()
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to create %s credential: %w", cli.credentialType(), err)
 	}
 
 	newCli, err := azsec.NewClient(cli.URI, cred, nil)
@@ -170,6 +245,13 @@ func (cli *Client) Warmup() error {
 		return err
 	}
 	cli.client = newCli
+	cli.cred = cred
+
+	keysCli, err := azkeys.NewClient(cli.URI, cred, nil)
+	if err != nil {
+		return err
+	}
+	cli.keys = keysCli
 
 	return nil
 }
@@ -5,10 +5,25 @@ package keyvault
 
 import (
 	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
 	"testing"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/authorization/armauthorization/v2"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azkeys"
 	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
 	"github.com/withholm/polyenv/internal/model"
 	"github.com/withholm/polyenv/internal/vaults/vaulttest"
@@ -21,9 +36,72 @@ func TestKeyVault(t *testing.T) {
 	})
 }
 
+func TestPullVersionAndMetadata(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(30 * 24 * time.Hour)
+
+	testCases := []struct {
+		name       string
+		version    string
+		attributes *azsecrets.SecretAttributes
+		expectErr  bool
+	}{
+		{name: "latest", version: "", attributes: &azsecrets.SecretAttributes{Enabled: to.Ptr(true)}},
+		{name: "pinned", version: "def456", attributes: &azsecrets.SecretAttributes{Enabled: to.Ptr(true), Expires: &future}},
+		{name: "no attributes", version: "", attributes: nil},
+		{name: "disabled", version: "", attributes: &azsecrets.SecretAttributes{Enabled: to.Ptr(false)}, expectErr: true},
+		{name: "expired", version: "", attributes: &azsecrets.SecretAttributes{Enabled: to.Ptr(true), Expires: &past}, expectErr: true},
+		{name: "not yet active", version: "", attributes: &azsecrets.SecretAttributes{Enabled: to.Ptr(true), NotBefore: &future}, expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mock := &mockAzsecretsClient{
+				GetSecretAttributes: tc.attributes,
+				GetSecretTags:       map[string]*string{"owner": to.Ptr("team")},
+			}
+			cli := &Client{client: mock}
+			content, err := cli.Pull(model.Secret{RemoteKey: "secret1", LocalKey: "SECRET1", Version: tc.version})
+			if mock.GetSecretVersion != tc.version {
+				t.Errorf("expected version %q to be requested, got %q", tc.version, mock.GetSecretVersion)
+			}
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tc.expectErr, err)
+			}
+			if tc.expectErr {
+				return
+			}
+			if content.Value != "value1" {
+				t.Errorf("expected value 'value1', got %q", content.Value)
+			}
+			// version is read from the id of the returned secret
+			expectVersion := tc.version
+			if expectVersion == "" {
+				expectVersion = "abc123"
+			}
+			if content.Version != expectVersion {
+				t.Errorf("expected version %q, got %q", expectVersion, content.Version)
+			}
+			if content.Tags["owner"] != "team" {
+				t.Errorf("expected tag owner=team, got %v", content.Tags)
+			}
+			if tc.attributes != nil && tc.attributes.Expires != nil && content.Expires == nil {
+				t.Error("expected expires to be set")
+			}
+		})
+	}
+}
+
 type mockAzsecretsClient struct {
 	// azsecretsClient
 	SetSecretCalled bool
+
+	GetSecretVersion    string
+	GetSecretAttributes *azsecrets.SecretAttributes
+	GetSecretTags       map[string]*string
+	// defaults to 'value1'
+	GetSecretValue       string
+	GetSecretContentType string
 }
 
 func (m *mockAzsecretsClient) NewListSecretPropertiesPager(options *azsecrets.ListSecretPropertiesOptions) *runtime.Pager[azsecrets.ListSecretPropertiesResponse] {
@@ -54,8 +132,28 @@ func (m *mockAzsecretsClient) NewListSecretPropertiesPager(options *azsecrets.Li
 }
 
 func (m *mockAzsecretsClient) GetSecret(ctx context.Context, name string, version string, options *azsecrets.GetSecretOptions) (azsecrets.GetSecretResponse, error) {
+	m.GetSecretVersion = version
+	value := m.GetSecretValue
+	if value == "" {
+		value = "value1"
+	}
+	var contentType *string
+	if m.GetSecretContentType != "" {
+		contentType = to.Ptr(m.GetSecretContentType)
+	}
+	// latest version is 'abc123'
+	if version == "" {
+		version = "abc123"
+	}
+	id := azsecrets.ID(fmt.Sprintf("https://kv/secrets/%s/%s", name, version))
 	return azsecrets.GetSecretResponse{
-		Secret: azsecrets.Secret{Value: to.Ptr("value1")},
+		Secret: azsecrets.Secret{
+			ID:          &id,
+			Value:       to.Ptr(value),
+			ContentType: contentType,
+			Attributes:  m.GetSecretAttributes,
+			Tags:        m.GetSecretTags,
+		},
 	}, nil
 }
 
@@ -63,3 +161,501 @@ func (m *mockAzsecretsClient) SetSecret(ctx context.Context, name string, parame
 	m.SetSecretCalled = true
 	return azsecrets.SetSecretResponse{}, nil
 }
+
+func TestPullElevate(t *testing.T) {
+	scope := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.KeyVault/vaults/kv"
+	roleID := func(guid string) string {
+		return "/subscriptions/sub/providers/Microsoft.Authorization/roleDefinitions/" + guid
+	}
+
+	testCases := []struct {
+		name           string
+		pim            bool
+		active         []pimRole
+		eligible       []pimRole
+		status         armauthorization.Status
+		expectActivate string
+		expectErr      bool
+	}{
+		{name: "pim disabled", pim: false},
+		{
+			name:   "already active",
+			pim:    true,
+			active: []pimRole{{RoleDefinitionID: roleID(roleKeyVaultSecretsOfficer), Scope: scope}},
+		},
+		{
+			name: "activates least privileged role",
+			pim:  true,
+			eligible: []pimRole{
+				{RoleDefinitionID: roleID(roleKeyVaultAdministrator), Scope: scope},
+				{RoleDefinitionID: roleID(roleKeyVaultSecretsUser), Scope: scope},
+			},
+			status:         armauthorization.StatusProvisioned,
+			expectActivate: roleKeyVaultSecretsUser,
+		},
+		{
+			name:      "no eligible role",
+			pim:       true,
+			eligible:  []pimRole{{RoleDefinitionID: roleID(roleKeyVaultReader), Scope: scope}},
+			expectErr: true,
+		},
+		{
+			name:           "activation denied",
+			pim:            true,
+			eligible:       []pimRole{{RoleDefinitionID: roleID(roleKeyVaultSecretsUser), Scope: scope}},
+			status:         armauthorization.StatusDenied,
+			expectActivate: roleKeyVaultSecretsUser,
+			expectErr:      true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mock := &mockPimClient{active: tc.active, eligible: tc.eligible, status: tc.status}
+			cli := &Client{PIM: tc.pim, ResourceID: scope, pim: mock}
+
+			err := cli.PullElevate(context.Background())
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tc.expectErr, err)
+			}
+
+			// second call should be cached
+			err2 := cli.PullElevate(context.Background())
+			if err2 != err {
+				t.Errorf("expected cached result %v, got %v", err, err2)
+			}
+
+			if tc.expectActivate == "" && mock.activations != 0 {
+				t.Errorf("expected no activation, got %d", mock.activations)
+			}
+			if tc.expectActivate != "" {
+				if mock.activations != 1 {
+					t.Fatalf("expected 1 activation, got %d", mock.activations)
+				}
+				if mock.activated.RoleGUID() != tc.expectActivate {
+					t.Errorf("expected role %s to be activated, got %s", tc.expectActivate, mock.activated.RoleGUID())
+				}
+				if mock.activation.Duration != defaultPimDuration {
+					t.Errorf("expected default duration, got %s", mock.activation.Duration)
+				}
+			}
+		})
+	}
+}
+
+func TestPullKeyElevate(t *testing.T) {
+	scope := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.KeyVault/vaults/kv"
+	roleID := func(guid string) string {
+		return "/providers/Microsoft.Authorization/roleDefinitions/" + guid
+	}
+	// secrets user is active, but it can not read keys
+	mock := &mockPimClient{
+		active:   []pimRole{{RoleDefinitionID: roleID(roleKeyVaultSecretsUser), Scope: scope}},
+		eligible: []pimRole{{RoleDefinitionID: roleID(roleKeyVaultCryptoUser), Scope: scope}},
+		status:   armauthorization.StatusProvisioned,
+	}
+	keys := &mockAzkeysClient{jwk: &azkeys.JSONWebKey{Kty: to.Ptr(azkeys.KeyTypeRSA), N: big.NewInt(65537).Bytes(), E: big.NewInt(3).Bytes()}, enabled: true}
+	cli := &Client{PIM: true, ResourceID: scope, pim: mock, client: &mockAzsecretsClient{}, keys: keys}
+
+	err := cli.PullElevate(context.Background())
+	if err != nil || mock.activations != 0 {
+		t.Fatalf("expected active secrets role to be used, got %v (%d activations)", err, mock.activations)
+	}
+	for range 2 {
+		_, err = cli.Pull(model.Secret{RemoteKey: "key", LocalKey: "KEY", Type: objectKey})
+		if err != nil {
+			t.Fatalf("Pull() returned an error: %v", err)
+		}
+	}
+	if mock.activations != 1 || mock.activated.RoleGUID() != roleKeyVaultCryptoUser {
+		t.Errorf("expected crypto user to be activated once, got %d activations of %s", mock.activations, mock.activated.RoleGUID())
+	}
+
+	// the context given to PullElevate is used for keys too
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	mock = &mockPimClient{
+		eligible: []pimRole{{RoleDefinitionID: roleID(roleKeyVaultAdministrator), Scope: scope}},
+		status:   armauthorization.StatusProvisioningStarted,
+	}
+	cli = &Client{PIM: true, ResourceID: scope, pim: mock, client: &mockAzsecretsClient{}, keys: keys}
+	cli.pullCtx = ctx
+	_, err = cli.Pull(model.Secret{RemoteKey: "key", LocalKey: "KEY", Type: objectKey})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestOidFromToken(t *testing.T) {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"oid":"00000000-0000-0000-0000-000000000001"}`))
+	oid, err := oidFromToken("header." + payload + ".signature")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if oid != "00000000-0000-0000-0000-000000000001" {
+		t.Errorf("unexpected oid: %s", oid)
+	}
+
+	_, err = oidFromToken("not-a-jwt")
+	if err == nil {
+		t.Error("expected error for invalid token")
+	}
+}
+
+type mockPimClient struct {
+	active   []pimRole
+	eligible []pimRole
+	status   armauthorization.Status
+
+	activations int
+	activated   pimRole
+	activation  pimActivation
+}
+
+func (m *mockPimClient) ListEligible(ctx context.Context, scope string) ([]pimRole, error) {
+	return m.eligible, nil
+}
+
+func (m *mockPimClient) ListActive(ctx context.Context, scope string) ([]pimRole, error) {
+	return m.active, nil
+}
+
+func (m *mockPimClient) Activate(ctx context.Context, role pimRole, act pimActivation) (string, error) {
+	m.activations++
+	m.activated = role
+	m.activation = act
+	return "request", nil
+}
+
+func (m *mockPimClient) RequestStatus(ctx context.Context, scope string, name string) (armauthorization.Status, error) {
+	return m.status, nil
+}
+
+func (m *mockPimClient) PrincipalID(ctx context.Context) (string, error) {
+	return "principal", nil
+}
+
+func TestWaitForActivationCancelled(t *testing.T) {
+	mock := &mockPimClient{status: armauthorization.StatusProvisioningStarted}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := waitForActivation(ctx, mock, "scope", "request", time.Hour, time.Hour)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("expected to return when cancelled, took %s", time.Since(start))
+	}
+}
+
+func TestCredential(t *testing.T) {
+	t.Setenv(envClientSecret, "")
+	t.Setenv(envClientCertificate, "")
+
+	testCases := []struct {
+		name      string
+		config    map[string]any
+		expectErr bool
+	}{
+		{name: "no credential uses default", config: map[string]any{}},
+		{name: "default", config: map[string]any{"credential": "default"}},
+		{name: "managed identity", config: map[string]any{"credential": "managed-identity", "client_id": "id"}},
+		{name: "device code", config: map[string]any{"credential": "device-code"}},
+		{name: "client secret without client id", config: map[string]any{"credential": "client-secret"}, expectErr: true},
+		{name: "client secret without env", config: map[string]any{"credential": "client-secret", "client_id": "id"}, expectErr: true},
+		{name: "client certificate without path", config: map[string]any{"credential": "client-certificate", "client_id": "id"}, expectErr: true},
+		{name: "client certificate missing file", config: map[string]any{"credential": "client-certificate", "client_id": "id", "certificate_path": "does-not-exist.pem"}, expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := map[string]any{"type": "keyvault", "tenant": "tenant", "uri": "https://example.vault.azure.net/"}
+			for k, v := range tc.config {
+				config[k] = v
+			}
+
+			cli := &Client{}
+			err := cli.Unmarshal(config)
+			if err != nil {
+				t.Fatalf("Unmarshal() returned an error: %v", err)
+			}
+
+			_, err = cli.newCredential()
+			if (err != nil) != tc.expectErr {
+				t.Errorf("expected error: %v, got: %v", tc.expectErr, err)
+			}
+
+			// credential settings should survive a marshal roundtrip
+			roundtrip := &Client{}
+			err = roundtrip.Unmarshal(cli.Marshal())
+			if err != nil {
+				t.Fatalf("Unmarshal() of marshalled config returned an error: %v", err)
+			}
+			if roundtrip.Credential != cli.Credential || roundtrip.ClientID != cli.ClientID || roundtrip.CertificatePath != cli.CertificatePath {
+				t.Errorf("credential settings not preserved: got %+v, want %+v", roundtrip.Marshal(), cli.Marshal())
+			}
+		})
+	}
+
+	t.Run("unknown credential", func(t *testing.T) {
+		cli := &Client{}
+		err := cli.Unmarshal(map[string]any{"type": "keyvault", "tenant": "tenant", "uri": "uri", "credential": "magic"})
+		if err == nil {
+			t.Error("expected error for unknown credential")
+		}
+	})
+}
+
+func TestPullCertificate(t *testing.T) {
+	certPem, keyPem := testCertificate(t)
+	mock := &mockAzsecretsClient{
+		GetSecretValue:       "garbage\n" + keyPem + certPem,
+		GetSecretContentType: contentTypePem,
+	}
+	cli := &Client{client: mock}
+
+	sec, err := cli.Pull(model.Secret{RemoteKey: "cert", LocalKey: "CERT", Type: objectCertificate})
+	if err != nil {
+		t.Fatalf("Pull() returned an error: %v", err)
+	}
+	if sec.ContentType != contentTypePem {
+		t.Errorf("expected content type %s, got %s", contentTypePem, sec.ContentType)
+	}
+	if sec.Value != certPem+keyPem {
+		t.Errorf("expected certificate followed by key, got %q", sec.Value)
+	}
+
+	mock.GetSecretContentType = "text/plain"
+	_, err = cli.Pull(model.Secret{RemoteKey: "cert", LocalKey: "CERT", Type: objectCertificate})
+	if err == nil {
+		t.Errorf("expected error for unsupported content type")
+	}
+
+	_, err = cli.Pull(model.Secret{RemoteKey: "cert", LocalKey: "CERT", Type: "blob"})
+	if err == nil {
+		t.Errorf("expected error for unsupported type")
+	}
+}
+
+func TestPullKey(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	ecPriv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	testCases := []struct {
+		name      string
+		jwk       *azkeys.JSONWebKey
+		enabled   bool
+		expectPub any
+		expectErr bool
+	}{
+		{
+			name:      "rsa",
+			jwk:       &azkeys.JSONWebKey{Kty: to.Ptr(azkeys.KeyTypeRSA), N: priv.N.Bytes(), E: big.NewInt(int64(priv.E)).Bytes()},
+			enabled:   true,
+			expectPub: &priv.PublicKey,
+		},
+		{
+			name:      "ec",
+			jwk:       &azkeys.JSONWebKey{Kty: to.Ptr(azkeys.KeyTypeECHSM), Crv: to.Ptr(azkeys.CurveNameP256), X: ecPriv.X.Bytes(), Y: ecPriv.Y.Bytes()},
+			enabled:   true,
+			expectPub: &ecPriv.PublicKey,
+		},
+		{
+			name:      "unsupported curve",
+			jwk:       &azkeys.JSONWebKey{Kty: to.Ptr(azkeys.KeyTypeEC), Crv: to.Ptr(azkeys.CurveNameP256K)},
+			enabled:   true,
+			expectErr: true,
+		},
+		{
+			name:      "disabled",
+			jwk:       &azkeys.JSONWebKey{Kty: to.Ptr(azkeys.KeyTypeRSA), N: priv.N.Bytes(), E: big.NewInt(int64(priv.E)).Bytes()},
+			enabled:   false,
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			keys := &mockAzkeysClient{jwk: tc.jwk, enabled: tc.enabled}
+			cli := &Client{client: &mockAzsecretsClient{}, keys: keys}
+
+			sec, err := cli.Pull(model.Secret{RemoteKey: "key", LocalKey: "KEY", Type: objectKey, Version: "v1"})
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Pull() returned an error: %v", err)
+			}
+			if keys.version != "v1" {
+				t.Errorf("expected version v1 to be requested, got %s", keys.version)
+			}
+
+			block, _ := pem.Decode([]byte(sec.Value))
+			if block == nil || block.Type != "PUBLIC KEY" {
+				t.Fatalf("expected PUBLIC KEY pem block, got %q", sec.Value)
+			}
+			pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				t.Fatalf("failed to parse public key: %v", err)
+			}
+			if !pub.(interface{ Equal(crypto.PublicKey) bool }).Equal(tc.expectPub) {
+				t.Errorf("public key does not match")
+			}
+		})
+	}
+}
+
+type mockAzkeysClient struct {
+	jwk     *azkeys.JSONWebKey
+	enabled bool
+	version string
+}
+
+func (m *mockAzkeysClient) GetKey(ctx context.Context, name string, version string, options *azkeys.GetKeyOptions) (azkeys.GetKeyResponse, error) {
+	m.version = version
+	return azkeys.GetKeyResponse{
+		KeyBundle: azkeys.KeyBundle{
+			Key:        m.jwk,
+			Attributes: &azkeys.KeyAttributes{Enabled: to.Ptr(m.enabled)},
+		},
+	}, nil
+}
+
+// generates a self signed certificate, returning certificate and key as pem
+func testCertificate(t *testing.T) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "polyenv"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	keyDer, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	certPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer})
+	return string(certPem), string(keyPem)
+}
+
+func TestManualVaultURI(t *testing.T) {
+	testCases := []struct {
+		name      string
+		uri       string
+		vaultName string
+		hasURI    bool
+		expect    string
+		expectErr bool
+	}{
+		{name: "uri", uri: "https://myvault.vault.azure.net", hasURI: true, expect: "https://myvault.vault.azure.net/"},
+		{name: "uri with slash", uri: "https://myvault.vault.usgovcloudapi.net/", hasURI: true, expect: "https://myvault.vault.usgovcloudapi.net/"},
+		{name: "uri without scheme", uri: "myvault.vault.azure.net", hasURI: true, expectErr: true},
+		{name: "name", vaultName: "My-Vault", expect: "https://my-vault.vault.azure.net/"},
+		{name: "invalid name", vaultName: "my_vault", expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := manualVaultURI(tc.uri, tc.vaultName, tc.hasURI)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected error, got %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("manualVaultURI() returned an error: %v", err)
+			}
+			if got != tc.expect {
+				t.Errorf("expected %s, got %s", tc.expect, got)
+			}
+		})
+	}
+}
+
+func TestWizardManual(t *testing.T) {
+	cli := &Client{}
+	err := cli.WizWarmup(map[string]any{"name": "myvault"})
+	if err != nil {
+		t.Fatalf("WizWarmup() returned an error: %v", err)
+	}
+	if !cli.wiz.Manual {
+		t.Errorf("expected manual mode when name is set")
+	}
+	if cli.wiz.URI != "https://myvault.vault.azure.net/" {
+		t.Errorf("unexpected uri %s", cli.wiz.URI)
+	}
+
+	// tenant is asked for once, then the wizard is done
+	f, err := cli.WizNext()
+	if err != nil || f == nil {
+		t.Fatalf("expected tenant form, got %v, %v", f, err)
+	}
+	f, err = cli.WizNext()
+	if err != nil || f != nil {
+		t.Fatalf("expected wizard to be done, got %v, %v", f, err)
+	}
+
+	// client-certificate asks for the certificate path first, unless it is defined
+	t.Setenv(envClientCertificate, "")
+	cli = &Client{}
+	err = cli.WizWarmup(map[string]any{"name": "myvault", "credential": "client-certificate"})
+	if err != nil {
+		t.Fatalf("WizWarmup() returned an error: %v", err)
+	}
+	if !cli.needsCertificatePath() {
+		t.Fatalf("expected certificate path to be asked for")
+	}
+	f, err = cli.WizNext()
+	if err != nil || f == nil {
+		t.Fatalf("expected certificate form, got %v, %v", f, err)
+	}
+	cli.wiz.CertificatePath = "cert.pem"
+	f, err = cli.WizNext()
+	if err != nil || f == nil {
+		t.Fatalf("expected tenant form, got %v, %v", f, err)
+	}
+	cli.wiz.Tenant = "00000000-0000-0000-0000-000000000000"
+	f, err = cli.WizNext()
+	if err != nil || f != nil {
+		t.Fatalf("expected wizard to be done, got %v, %v", f, err)
+	}
+
+	// pim cannot look up the scope in manual mode
+	cli = &Client{}
+	err = cli.WizWarmup(map[string]any{"name": "myvault", "pim": true})
+	if err == nil {
+		t.Errorf("expected error when pim is used in manual mode without resource_id")
+	}
+	cli = &Client{}
+	err = cli.WizWarmup(map[string]any{"name": "myvault", "pim": true, "resource_id": "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.KeyVault/vaults/myvault"})
+	if err != nil {
+		t.Errorf("WizWarmup() returned an error: %v", err)
+	}
+
+	cli = &Client{client: &mockAzsecretsClient{}}
+	err = cli.checkAccess(context.Background())
+	if err != nil {
+		t.Errorf("checkAccess() returned an error: %v", err)
+	}
+}
@@ -37,24 +37,27 @@ func (cli *Client) List() (out []model.Secret, err error) {
 				ctype = *secret.ContentType
 			}
 
-			enabled := false
-			if secret.Attributes != nil && secret.Attributes.Enabled != nil {
-				enabled = *secret.Attributes.Enabled
-			}
-
-			out = append(out, model.Secret{
+			sec := model.Secret{
 				ContentType: ctype,
-				Enabled:     enabled,
 				RemoteKey:   secret.ID.Name(),
-			})
+			}
+			if secret.Attributes != nil {
+				if secret.Attributes.Enabled != nil {
+					sec.Enabled = *secret.Attributes.Enabled
+				}
+				sec.Expires = secret.Attributes.Expires
+				sec.NotBefore = secret.Attributes.NotBefore
+			}
+
+			out = append(out, sec)
 		}
 	}
 	return out, nil
 }
 
-func (cli *Client) ListElevate() error {
+func (cli *Client) ListElevate(ctx context.Context) error {
 	cli.listElevateOnce.Do(func() {
-		slog.Debug("Keyvault PIM elevate not implemented yet")
+		cli.listElevateErr = cli.elevate(ctx, "list", pimListRoles)
 	})
-	return nil
+	return cli.listElevateErr
 }
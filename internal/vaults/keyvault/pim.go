@@ -0,0 +1,386 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.
+// If a copy of the MPL was not distributed with this file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package keyvault
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/authorization/armauthorization/v2"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resourcegraph/armresourcegraph"
+	"github.com/google/uuid"
+	"github.com/sosodev/duration"
+)
+
+// built-in role definition ids for key vault data plane
+const (
+	roleKeyVaultAdministrator  = "00482a5a-887f-4fb3-b363-3b7fe8e74483"
+	roleKeyVaultSecretsOfficer = "b86a8fe4-44ce-4948-aee5-eccb2c155cd7"
+	roleKeyVaultSecretsUser    = "4633458b-17de-408a-b874-0445c86b69e6"
+	roleKeyVaultReader         = "21090545-7ca7-4776-b22c-e363652d74d2"
+	roleKeyVaultCryptoUser     = "12338af0-0e69-4776-bea7-57ae8d297424"
+	roleKeyVaultCryptoOfficer  = "14b46e9e-c2b7-41b4-b07b-48a6ebf60603"
+)
+
+// roles that can be activated for each operation, in order of preference (least privilege first)
+var (
+	pimPullRoles = []string{roleKeyVaultSecretsUser, roleKeyVaultSecretsOfficer, roleKeyVaultAdministrator}
+	// keys are read with the keys api, only the public part is pulled
+	pimPullKeyRoles = []string{roleKeyVaultReader, roleKeyVaultCryptoUser, roleKeyVaultCryptoOfficer, roleKeyVaultAdministrator}
+	pimPushRoles    = []string{roleKeyVaultSecretsOfficer, roleKeyVaultAdministrator}
+	pimListRoles    = []string{roleKeyVaultReader, roleKeyVaultSecretsUser, roleKeyVaultSecretsOfficer, roleKeyVaultAdministrator}
+)
+
+const (
+	defaultPimDuration      = "PT1H"
+	defaultPimJustification = "activated by polyenv"
+	pimActivationTimeout    = 3 * time.Minute
+	pimPollInterval         = 5 * time.Second
+)
+
+// a role eligibility or assignment for the current principal
+type pimRole struct {
+	RoleDefinitionID string
+	Scope            string
+	// id of the eligibility schedule. only set for eligible roles
+	EligibilityScheduleID string
+}
+
+// guid of the role definition (last part of the role definition id)
+func (r pimRole) RoleGUID() string {
+	idx := strings.LastIndex(r.RoleDefinitionID, "/")
+	return strings.ToLower(r.RoleDefinitionID[idx+1:])
+}
+
+type pimActivation struct {
+	PrincipalID   string
+	Justification string
+	Duration      string
+}
+
+// subset of arm authorization used for PIM. makes it possible to mock in tests
+type pimClient interface {
+	// list roles the current principal is eligible for at or above scope
+	ListEligible(ctx context.Context, scope string) ([]pimRole, error)
+	// list roles that are currently active for the current principal at or above scope
+	ListActive(ctx context.Context, scope string) ([]pimRole, error)
+	// request self activation of an eligible role. returns the name of the request
+	Activate(ctx context.Context, role pimRole, act pimActivation) (string, error)
+	// get status of an activation request
+	RequestStatus(ctx context.Context, scope string, name string) (armauthorization.Status, error)
+	// object id of the current principal
+	PrincipalID(ctx context.Context) (string, error)
+}
+
+// elevate to one of the given roles using PIM. does nothing if pim is not enabled for the vault.
+// waiting for the activation stops when ctx is cancelled
+func (cli *Client) elevate(ctx context.Context, operation string, roles []string) error {
+	if !cli.PIM {
+		slog.Debug("Keyvault PIM not enabled for vault. skipping elevation", "operation", operation)
+		return nil
+	}
+
+	if cli.pim == nil {
+		if cli.cred == nil {
+			return fmt.Errorf("client not initialized. warmup first")
+		}
+		pimCli, err := newArmPimClient(cli.cred)
+		if err != nil {
+			return err
+		}
+		cli.pim = pimCli
+	}
+
+	scope, err := cli.pimScope(ctx)
+	if err != nil {
+		return err
+	}
+
+	active, err := cli.pim.ListActive(ctx, scope)
+	if err != nil {
+		return fmt.Errorf("failed to list active role assignments: %w", err)
+	}
+	for _, role := range active {
+		if slices.Contains(roles, role.RoleGUID()) {
+			slog.Debug("Keyvault PIM role already active", "operation", operation, "role", role.RoleDefinitionID, "scope", role.Scope)
+			return nil
+		}
+	}
+
+	eligible, err := cli.pim.ListEligible(ctx, scope)
+	if err != nil {
+		return fmt.Errorf("failed to list eligible role assignments: %w", err)
+	}
+
+	var selected *pimRole
+	for _, want := range roles {
+		idx := slices.IndexFunc(eligible, func(r pimRole) bool { return r.RoleGUID() == want })
+		if idx >= 0 {
+			selected = &eligible[idx]
+			break
+		}
+	}
+	if selected == nil {
+		return fmt.Errorf("no eligible key vault role found for %s on %s. make sure you are eligible for one of the roles in PIM", operation, scope)
+	}
+
+	principal, err := cli.pim.PrincipalID(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get principal id: %w", err)
+	}
+
+	act := pimActivation{
+		PrincipalID:   principal,
+		Justification: cli.PIMJustification,
+		Duration:      cli.PIMDuration,
+	}
+	if act.Justification == "" {
+		act.Justification = defaultPimJustification
+	}
+	if act.Duration == "" {
+		act.Duration = defaultPimDuration
+	}
+
+	slog.Info("activating key vault role through PIM", "operation", operation, "role", selected.RoleGUID(), "scope", selected.Scope, "duration", act.Duration)
+	name, err := cli.pim.Activate(ctx, *selected, act)
+	if err != nil {
+		return fmt.Errorf("failed to activate role: %w", err)
+	}
+
+	return waitForActivation(ctx, cli.pim, selected.Scope, name, pimActivationTimeout, pimPollInterval)
+}
+
+// poll the activation request until it is provisioned, fails, times out or ctx is cancelled
+func waitForActivation(ctx context.Context, pim pimClient, scope string, name string, timeout time.Duration, interval time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		status, err := pim.RequestStatus(ctx, scope, name)
+		if err != nil {
+			return fmt.Errorf("failed to get activation status: %w", err)
+		}
+		slog.Debug("Keyvault PIM activation status", "request", name, "status", status)
+
+		switch status {
+		case armauthorization.StatusProvisioned:
+			return nil
+		case armauthorization.StatusPendingApproval, armauthorization.StatusPendingApprovalProvisioning, armauthorization.StatusPendingAdminDecision:
+			return fmt.Errorf("role activation requires approval. run again once request %s is approved", name)
+		case armauthorization.StatusDenied, armauthorization.StatusAdminDenied, armauthorization.StatusFailed,
+			armauthorization.StatusFailedAsResourceIsLocked, armauthorization.StatusCanceled, armauthorization.StatusInvalid,
+			armauthorization.StatusRevoked, armauthorization.StatusTimedOut:
+			return fmt.Errorf("role activation ended with status %s", status)
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for role activation (last status: %s)", status)
+		}
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// returns the arm resource id of the vault. resolves it using resource graph if not set
+func (cli *Client) pimScope(ctx context.Context) (string, error) {
+	if cli.ResourceID != "" {
+		return cli.ResourceID, nil
+	}
+
+	slog.Debug("resource id not set for vault. resolving using resource graph", "uri", cli.URI)
+	factory, err := armresourcegraph.NewClientFactory(cli.cred, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create client factory: %w", err)
+	}
+
+	uri := strings.TrimSuffix(strings.ToLower(cli.URI), "/")
+	query := fmt.Sprintf("resources| where type == 'microsoft.keyvault/vaults' and trim('/', tolower(tostring(properties.vaultUri))) == '%s'|project id", uri)
+	res, err := factory.NewClient().Resources(ctx, armresourcegraph.QueryRequest{
+		Query: to.Ptr(query),
+		Options: &armresourcegraph.QueryRequestOptions{
+			ResultFormat: to.Ptr(armresourcegraph.ResultFormatObjectArray),
+		},
+	}, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve resource id of %s: %w", cli.URI, err)
+	}
+
+	items, ok := res.Data.([]interface{})
+	if !ok || len(items) == 0 {
+		return "", fmt.Errorf("could not find key vault %s using resource graph. set 'resource_id' on the vault", cli.URI)
+	}
+	item, ok := items[0].(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("unexpected Resource Graph result format: %T", items[0])
+	}
+	id, ok := item["id"].(string)
+	if !ok || id == "" {
+		return "", fmt.Errorf("resource graph did not return an id for %s", cli.URI)
+	}
+
+	cli.ResourceID = id
+	return id, nil
+}
+
+// validates an ISO 8601 duration used for PIM activation
+func validatePimDuration(s string) error {
+	dur, err := duration.Parse(s)
+	if err != nil {
+		return fmt.Errorf("invalid pim_duration '%s'. expected ISO 8601 duration, e.g. PT1H: %w", s, err)
+	}
+	if dur.ToTimeDuration() <= 0 {
+		return fmt.Errorf("pim_duration must be larger than 0")
+	}
+	return nil
+}
+
+//region arm
+
+// pim client using azure resource manager
+type armPimClient struct {
+	cred    azcore.TokenCredential
+	factory *armauthorization.ClientFactory
+}
+
+func newArmPimClient(cred azcore.TokenCredential) (*armPimClient, error) {
+	// subscription is not used for scope based clients
+	factory, err := armauthorization.NewClientFactory("", cred, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client factory: %w", err)
+	}
+	return &armPimClient{cred: cred, factory: factory}, nil
+}
+
+func (c *armPimClient) ListEligible(ctx context.Context, scope string) (out []pimRole, err error) {
+	pager := c.factory.NewRoleEligibilityScheduleInstancesClient().NewListForScopePager(scope, &armauthorization.RoleEligibilityScheduleInstancesClientListForScopeOptions{
+		Filter: to.Ptr("asTarget()"),
+	})
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range page.Value {
+			if v.Properties == nil || v.Properties.RoleDefinitionID == nil {
+				continue
+			}
+			out = append(out, pimRole{
+				RoleDefinitionID:      *v.Properties.RoleDefinitionID,
+				Scope:                 derefString(v.Properties.Scope),
+				EligibilityScheduleID: derefString(v.Properties.RoleEligibilityScheduleID),
+			})
+		}
+	}
+	return out, nil
+}
+
+func (c *armPimClient) ListActive(ctx context.Context, scope string) (out []pimRole, err error) {
+	pager := c.factory.NewRoleAssignmentScheduleInstancesClient().NewListForScopePager(scope, &armauthorization.RoleAssignmentScheduleInstancesClientListForScopeOptions{
+		Filter: to.Ptr("asTarget()"),
+	})
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range page.Value {
+			if v.Properties == nil || v.Properties.RoleDefinitionID == nil {
+				continue
+			}
+			out = append(out, pimRole{
+				RoleDefinitionID: *v.Properties.RoleDefinitionID,
+				Scope:            derefString(v.Properties.Scope),
+			})
+		}
+	}
+	return out, nil
+}
+
+func (c *armPimClient) Activate(ctx context.Context, role pimRole, act pimActivation) (string, error) {
+	name := uuid.NewString()
+	_, err := c.factory.NewRoleAssignmentScheduleRequestsClient().Create(ctx, role.Scope, name, armauthorization.RoleAssignmentScheduleRequest{
+		Properties: &armauthorization.RoleAssignmentScheduleRequestProperties{
+			PrincipalID:                     to.Ptr(act.PrincipalID),
+			RoleDefinitionID:                to.Ptr(role.RoleDefinitionID),
+			RequestType:                     to.Ptr(armauthorization.RequestTypeSelfActivate),
+			LinkedRoleEligibilityScheduleID: to.Ptr(role.EligibilityScheduleID),
+			Justification:                   to.Ptr(act.Justification),
+			ScheduleInfo: &armauthorization.RoleAssignmentScheduleRequestPropertiesScheduleInfo{
+				StartDateTime: to.Ptr(time.Now()),
+				Expiration: &armauthorization.RoleAssignmentScheduleRequestPropertiesScheduleInfoExpiration{
+					Type:     to.Ptr(armauthorization.TypeAfterDuration),
+					Duration: to.Ptr(act.Duration),
+				},
+			},
+		},
+	}, nil)
+	if err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+func (c *armPimClient) RequestStatus(ctx context.Context, scope string, name string) (armauthorization.Status, error) {
+	res, err := c.factory.NewRoleAssignmentScheduleRequestsClient().Get(ctx, scope, name, nil)
+	if err != nil {
+		return "", err
+	}
+	if res.Properties == nil || res.Properties.Status == nil {
+		return "", nil
+	}
+	return *res.Properties.Status, nil
+}
+
+// reads the object id (oid) from an arm access token
+func (c *armPimClient) PrincipalID(ctx context.Context) (string, error) {
+	tok, err := c.cred.GetToken(ctx, policy.TokenRequestOptions{
+		Scopes: []string{"https://management.azure.com/.default"},
+	})
+	if err != nil {
+		return "", err
+	}
+	return oidFromToken(tok.Token)
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// extracts the oid claim from a jwt without validating it
+func oidFromToken(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("access token is not a jwt")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("failed to decode access token: %w", err)
+	}
+	var claims struct {
+		Oid string `json:"oid"`
+	}
+	err = json.Unmarshal(payload, &claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse access token: %w", err)
+	}
+	if claims.Oid == "" {
+		return "", fmt.Errorf("access token does not contain an oid claim")
+	}
+	return claims.Oid, nil
+}
@@ -7,18 +7,49 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/withholm/polyenv/internal/model"
 )
 
+// warn when a pulled secret expires within this window
+const expiryWarningWindow = 7 * 24 * time.Hour
+
+// object types that can be pulled from keyvault
+const (
+	objectSecret      = "secret"
+	objectCertificate = "certificate"
+	objectKey         = "key"
+)
+
 func (cli *Client) Pull(s model.Secret) (model.SecretContent, error) {
+	switch s.Type {
+	case "", objectSecret:
+		return cli.pullSecret(s)
+	case objectCertificate:
+		return cli.pullCertificate(s)
+	case objectKey:
+		err := cli.pullKeyElevate()
+		if err != nil {
+			return model.SecretContent{}, fmt.Errorf("failed to elevate for key %s: %w", s.RemoteKey, err)
+		}
+		return cli.pullKey(s)
+	}
+	return model.SecretContent{}, fmt.Errorf("unsupported type '%s' for %s. supported types: %s, %s, %s", s.Type, s.RemoteKey, objectSecret, objectCertificate, objectKey)
+}
+
+func (cli *Client) pullSecret(s model.Secret) (model.SecretContent, error) {
 	var sec model.SecretContent
 	if cli.client == nil {
 		return sec, fmt.Errorf("client not initialized. warmup first")
 	}
 
-	kvSecret, err := cli.client.GetSecret(context.Background(), s.RemoteKey, "", nil)
+	// empty version will return latest
+	kvSecret, err := cli.client.GetSecret(context.Background(), s.RemoteKey, s.Version, nil)
 	if err != nil {
+		if s.Version != "" {
+			return sec, fmt.Errorf("failed to read secret %s (version %s): %w", s.RemoteKey, s.Version, err)
+		}
 		return sec, fmt.Errorf("failed to read secret %s: %w", s.RemoteKey, err)
 	}
 
@@ -28,17 +59,84 @@ func (cli *Client) Pull(s model.Secret) (model.SecretContent, error) {
 	if kvSecret.Value != nil {
 		sec.Value = *kvSecret.Value
 	}
+	if kvSecret.ID != nil {
+		sec.Version = kvSecret.ID.Version()
+	}
 	sec.RemoteKey = s.RemoteKey
 	sec.LocalKey = s.LocalKey
+	sec.Tags = convertTags(kvSecret.Tags)
+
+	// secrets without attributes are treated as enabled with no expiry
+	sec.Enabled = true
+	if kvSecret.Attributes != nil {
+		if kvSecret.Attributes.Enabled != nil {
+			sec.Enabled = *kvSecret.Attributes.Enabled
+		}
+		sec.Expires = kvSecret.Attributes.Expires
+		sec.NotBefore = kvSecret.Attributes.NotBefore
+	}
+
+	err = checkSecretValidity(sec, time.Now())
+	if err != nil {
+		return model.SecretContent{}, err
+	}
 
 	return sec, nil
 }
 
-// var elevated = false
+// refuses disabled, expired or not yet active secrets. warns if secret is about to expire
+func checkSecretValidity(sec model.SecretContent, now time.Time) error {
+	if !sec.Enabled {
+		return fmt.Errorf("secret %s (version %s) is disabled", sec.RemoteKey, sec.Version)
+	}
 
-func (cli *Client) PullElevate() error {
+	if sec.NotBefore != nil && now.Before(*sec.NotBefore) {
+		return fmt.Errorf("secret %s (version %s) is not active before %s", sec.RemoteKey, sec.Version, sec.NotBefore.Format(time.RFC3339))
+	}
+
+	if sec.Expires != nil {
+		if !now.Before(*sec.Expires) {
+			return fmt.Errorf("secret %s (version %s) expired at %s", sec.RemoteKey, sec.Version, sec.Expires.Format(time.RFC3339))
+		}
+		if sec.Expires.Sub(now) < expiryWarningWindow {
+			slog.Warn("secret is about to expire", "secret", sec.RemoteKey, "version", sec.Version, "expires", sec.Expires.Format(time.RFC3339))
+		}
+	}
+	return nil
+}
+
+// converts keyvault tags to a plain map
+func convertTags(tags map[string]*string) map[string]string {
+	if len(tags) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(tags))
+	for k, v := range tags {
+		if v == nil {
+			out[k] = ""
+			continue
+		}
+		out[k] = *v
+	}
+	return out
+}
+
+func (cli *Client) PullElevate(ctx context.Context) error {
+	cli.pullCtx = ctx
 	cli.pullElevateOnce.Do(func() {
-		slog.Debug("Keyvault PIM elevate not implemented yet. returning no error")
+		cli.pullElevateErr = cli.elevate(ctx, "pull", pimPullRoles)
 	})
-	return nil
+	return cli.pullElevateErr
+}
+
+// elevates to a role that can read keys. uses the context given to PullElevate
+func (cli *Client) pullKeyElevate() error {
+	cli.pullKeyElevateOnce.Do(func() {
+		ctx := cli.pullCtx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		cli.pullKeyElevateErr = cli.elevate(ctx, "pull key", pimPullKeyRoles)
+	})
+	return cli.pullKeyElevateErr
 }
@@ -32,9 +32,9 @@ func (cli *Client) Push(s model.SecretContent) error {
 	return nil
 }
 
-func (cli *Client) PushElevate() error {
+func (cli *Client) PushElevate(ctx context.Context) error {
 	cli.pushElevateOnce.Do(func() {
-		slog.Debug("Keyvault PIM elevate not implemented yet")
+		cli.pushElevateErr = cli.elevate(ctx, "push", pimPushRoles)
 	})
-	return nil
+	return cli.pushElevateErr
 }
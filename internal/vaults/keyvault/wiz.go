@@ -4,10 +4,13 @@
 package keyvault
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/huh"
@@ -21,10 +24,19 @@ type (
 		Subscriptions []string
 		URI           string
 		Name          string
-		state         int
+		PIM           bool
+		Credential    string
+		ClientID      string
+		//path to the certificate used by client-certificate
+		CertificatePath string
+		ResourceID      string
+		//uri or name was given. skips tenant, subscription and vault enumeration
+		Manual bool
+		state  int
 	}
 
 	GraphQueryItem struct {
+		ID             string
 		Name           string
 		ResourceGroup  string
 		SubscriptionID string
@@ -59,33 +71,78 @@ func (cli *Client) WizWarmup(m map[string]any) error {
 		cli.wiz.Subscription = m["sub"].(string)
 	}
 
-	// if m["uri"] != nil {
-	// 	cli.wiz.Uri = m["uri"].(string)
-	// }
+	if m["pim"] != nil {
+		pim, e := strconv.ParseBool(fmt.Sprint(m["pim"]))
+		if e != nil {
+			return fmt.Errorf("invalid value for pim: %w", e)
+		}
+		cli.wiz.PIM = pim
+	}
+
+	if m["uri"] != nil || m["name"] != nil {
+		uri, e := manualVaultURI(fmt.Sprint(m["uri"]), fmt.Sprint(m["name"]), m["uri"] != nil)
+		if e != nil {
+			return e
+		}
+		cli.wiz.URI = uri
+		cli.wiz.Manual = true
+		slog.Debug("manual mode, skipping resource graph", "uri", uri)
+	}
+
+	if m["resource_id"] != nil {
+		cli.wiz.ResourceID = m["resource_id"].(string)
+	}
+
+	// pim needs the resource id as scope. in manual mode there might not be access to resource graph to look it up
+	if cli.wiz.PIM && cli.wiz.Manual && cli.wiz.ResourceID == "" {
+		return fmt.Errorf("resource_id is required when using pim with uri or name. add --arg resource_id=/subscriptions/.../providers/Microsoft.KeyVault/vaults/<name>")
+	}
+
+	if m["credential"] != nil {
+		cli.wiz.Credential = m["credential"].(string)
+		e := validateCredentialType(cli.wiz.Credential)
+		if e != nil {
+			return e
+		}
+	}
 
-	// if m["name"] != nil {
-	// 	cli.wiz.Name = m["name"].(string)
-	// }
+	if m["client_id"] != nil {
+		cli.wiz.ClientID = m["client_id"].(string)
+	}
+
+	if m["certificate_path"] != nil {
+		cli.wiz.CertificatePath = m["certificate_path"].(string)
+	}
 
+	known := []string{"tenant", "subscription", "sub", "uri", "name", "pim", "credential", "client_id", "certificate_path", "resource_id"}
 	for k := range m {
-		if k == "tenant" || k == "subscription" || k == "sub" || k == "uri" || k == "name" {
+		if slices.Contains(known, k) {
 			continue
 		}
 		v := m[k]
 		slog.Warn("unknown key for keyvault wizard", "key", k, "value", v)
 	}
 
-	err := checkAzCliInstalled()
-	if err != nil {
-		return err
+	if CredentialType(cli.wiz.Credential) == CredentialCli {
+		err := checkAzCliInstalled()
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
 func (cli *Client) WizNext() (*huh.Form, error) {
+	// asked for before the vault, so it does not change the state
+	if cli.needsCertificatePath() {
+		return cli.wizCertificatePath(), nil
+	}
 	// automatically increment the formGroup
 	defer func() { cli.wiz.state++ }()
+	if cli.wiz.Manual {
+		return cli.wizNextManual()
+	}
 	switch cli.wiz.state {
 	case 0: //select tenant
 		if cli.wiz.Tenant != "" {
@@ -216,13 +273,122 @@ func (cli *Client) WizNext() (*huh.Form, error) {
 func (cli *Client) WizComplete() error {
 	cli.Tenant = cli.wiz.Tenant
 	cli.URI = cli.wiz.URI
+	cli.PIM = cli.wiz.PIM
+	cli.Credential = cli.wiz.Credential
+	cli.ClientID = cli.wiz.ClientID
+	cli.CertificatePath = cli.wiz.CertificatePath
+	cli.ResourceID = cli.wiz.ResourceID
+	if cli.ResourceID == "" && !cli.wiz.Manual {
+		cli.ResourceID = getCachedKeyvaultID(cli.wiz.Tenant, cli.wiz.URI)
+	}
 	err := cli.Warmup()
 	if err != nil {
 		return fmt.Errorf("failed to warmup vault: %w", err)
 	}
+
+	// nothing was enumerated, so make sure the vault is actually reachable
+	if cli.wiz.Manual {
+		err = cli.checkAccess(context.Background())
+		if err != nil {
+			return fmt.Errorf("failed to access %s: %w", cli.URI, err)
+		}
+	}
 	return nil
 	// return map[string]any{
 	// 	"tenant": cli.wiz.Tenant,
 	// 	"uri":    cli.wiz.Uri,
 	// }, nil
 }
+
+// wizard for when the vault is given by uri or name. only asks for tenant if it's not defined
+func (cli *Client) wizNextManual() (*huh.Form, error) {
+	if cli.wiz.state > 0 || cli.wiz.Tenant != "" {
+		return nil, nil
+	}
+
+	var tenant string
+	return huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Tenant").
+				Description("tenant id or domain of " + cli.wiz.URI).
+				Validate(func(s string) error {
+					t, e := GetTenant(s)
+					if e != nil {
+						return fmt.Errorf("could not resolve tenant: %w", e)
+					}
+					cli.wiz.Tenant = t
+					return nil
+				}).
+				Value(&tenant),
+		),
+	), nil
+}
+
+// client-certificate needs a certificate, either from certificate_path or env
+func (cli *Client) needsCertificatePath() bool {
+	if CredentialType(cli.wiz.Credential) != CredentialClientCertificate || cli.wiz.CertificatePath != "" {
+		return false
+	}
+	if os.Getenv(envClientCertificate) != "" {
+		slog.Debug("using certificate from env", "env", envClientCertificate)
+		return false
+	}
+	return true
+}
+
+// asks for the path to the certificate used by client-certificate
+func (cli *Client) wizCertificatePath() *huh.Form {
+	var path string
+	return huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Certificate path").
+				Description("path to the pem or pfx certificate of the app registration. can also be set with " + envClientCertificate).
+				Validate(func(s string) error {
+					if s == "" {
+						return fmt.Errorf("certificate path is required for %s", CredentialClientCertificate)
+					}
+					_, e := os.Stat(s)
+					if e != nil {
+						return fmt.Errorf("could not read certificate: %w", e)
+					}
+					cli.wiz.CertificatePath = s
+					return nil
+				}).
+				Value(&path),
+		),
+	)
+}
+
+// returns the vault uri from either uri or name. name is expanded to a public cloud uri
+func manualVaultURI(uri, name string, hasURI bool) (string, error) {
+	if hasURI {
+		if !strings.HasPrefix(uri, "https://") {
+			return "", fmt.Errorf("invalid uri '%s'. expected https://<name>.vault.azure.net", uri)
+		}
+		return strings.TrimSuffix(uri, "/") + "/", nil
+	}
+
+	regexName := regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9-]{1,22}[a-zA-Z0-9]$`)
+	if !regexName.MatchString(name) {
+		return "", fmt.Errorf("invalid keyvault name '%s'", name)
+	}
+	return fmt.Sprintf("https://%s.vault.azure.net/", strings.ToLower(name)), nil
+}
+
+// checks that the current identity can list secrets. only requests the first page
+func (cli *Client) checkAccess(ctx context.Context) error {
+	if cli.PIM {
+		err := cli.ListElevate(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to elevate: %w", err)
+		}
+	}
+	pager := cli.client.NewListSecretPropertiesPager(nil)
+	if !pager.More() {
+		return nil
+	}
+	_, err := pager.NextPage(ctx)
+	return err
+}
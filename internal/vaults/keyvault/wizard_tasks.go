@@ -114,7 +114,7 @@ func getKeyvaults(subID string, tenID string) (out []GraphQueryItem, err error)
 	client := clientFactory.NewClient()
 
 	// var err error
-	projections := []string{"name", "resourceGroup", "subscriptionId", "tenantId", "location", "vaultUri = properties.vaultUri"}
+	projections := []string{"id", "name", "resourceGroup", "subscriptionId", "tenantId", "location", "vaultUri = properties.vaultUri"}
 	// query := fmt.Sprintf("resources| where type == 'microsoft.keyvault/vaults' and subscriptionId == '%s'|project %s", subId, strings.Join(projections, ","))
 	query := fmt.Sprintf("resources| where type == 'microsoft.keyvault/vaults'|project %s", strings.Join(projections, ","))
 
@@ -186,3 +186,15 @@ func getKeyvaults(subID string, tenID string) (out []GraphQueryItem, err error)
 
 	return o, nil
 }
+
+// returns the resource id of a keyvault found by a previous resource graph query. empty if not found
+func getCachedKeyvaultID(tenID string, uri string) string {
+	cacheMutex.RLock()
+	defer cacheMutex.RUnlock()
+	for _, v := range queryCache[tenID] {
+		if strings.EqualFold(v.VaultURI, uri) {
+			return v.ID
+		}
+	}
+	return ""
+}
@@ -5,6 +5,7 @@
 package local
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
@@ -184,7 +185,7 @@ func (c *Client) Push(s model.SecretContent) error {
 	return fmt.Errorf("push is not supported for manual vaults")
 }
 
-func (c *Client) PushElevate() error {
+func (c *Client) PushElevate(ctx context.Context) error {
 	return nil
 }
 
@@ -220,14 +221,14 @@ func (c *Client) Pull(s model.Secret) (model.SecretContent, error) {
 	return sec, nil
 }
 
-func (c *Client) PullElevate() error {
+func (c *Client) PullElevate(ctx context.Context) error {
 	return nil
 }
 
 //endregion
 
 // region List
-func (c *Client) ListElevate() error {
+func (c *Client) ListElevate(ctx context.Context) error {
 	return nil
 }
 